@@ -1,25 +1,38 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"quiz-app/internal/config"
 	"quiz-app/internal/handler"
-	"quiz-app/internal/model"
+	"quiz-app/internal/logging"
+	"quiz-app/internal/metrics"
+	"quiz-app/internal/migration"
 	"quiz-app/internal/repository"
 	"quiz-app/internal/service"
+	"quiz-app/internal/tracing"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
 	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func main() {
 	env := flag.String("env", "", "Environment: prod, dev, local")
+	storage := flag.String("storage", "database", "Storage backend: database (driver from config, with Redis) or memory")
 	flag.Parse()
 	if *env == "" {
 		*env = os.Getenv("APP_ENV")
@@ -28,63 +41,319 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to load configuration:", err)
 	}
+	if err := logging.Setup(cfg.Log, os.Stderr); err != nil {
+		log.Fatal("Failed to configure logging:", err)
+	}
+
+	if flag.Arg(0) == "migrate" {
+		runMigrate(cfg, flag.Args()[1:])
+		return
+	}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		cfg.Database.Host, cfg.Database.User, cfg.Database.Password,
-		cfg.Database.Name, cfg.Database.Port, cfg.Database.SSLMode,
-	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("Failed to configure tracing", "error", err)
 	}
 
-	// Auto migrate
-	db.AutoMigrate(&model.User{}, &model.QuizSession{}, &model.Question{}, &model.UserAnswer{})
+	repos := newRepositories(cfg, *storage)
+	quizRepo, redisRepo := repos.quiz, repos.redis
 
-	// Redis connection
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.Addr,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
+	// Initialize services
+	wsService := service.NewWebSocketService(redisRepo)
+	seriesService := service.NewSeriesService(quizRepo, redisRepo, wsService)
+	presenceService := service.NewPresenceService(redisRepo, wsService, cfg.Presence)
+	quizService := service.NewQuizService(quizRepo, redisRepo, wsService, seriesService, presenceService, cfg.Redis)
+	analyticsService := service.NewAnalyticsService(quizRepo, quizService)
+	cleanupWorker := service.NewCleanupWorker(quizService, quizRepo, cfg.Cleanup)
+	quizScheduler := service.NewQuizScheduler(quizService, quizRepo, redisRepo, wsService, cfg.Scheduler)
+	healthService := service.NewHealthService(repos.checks...)
+
+	// Metrics read on every scrape
+	metrics.RegisterCacheStats(quizService.CacheStats)
+	metrics.RegisterActiveQuizzes(func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		ids, err := quizRepo.GetQuizIDsByStatus(ctx, "active")
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to count active quizzes for metrics", "error", err)
+			return 0
+		}
+		return float64(len(ids))
 	})
 
-	// Initialize repositories
-	quizRepo := repository.NewQuizRepository(db)
-	redisRepo := repository.NewRedisRepository(rdb)
+	// Background workers stop with the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
+	for _, run := range append([]func(context.Context){cleanupWorker.Run, quizScheduler.Run, presenceService.Run}, repos.workers...) {
+		workers.Add(1)
+		go func(run func(context.Context)) {
+			defer workers.Done()
+			run(ctx)
+		}(run)
+	}
 
-	// Initialize services
-	wsService := service.NewWebSocketService(redisRepo)
-	quizService := service.NewQuizService(quizRepo, redisRepo, wsService)
+	if cfg.Redis.WarmupOnStart {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			warmed, err := quizService.WarmupActiveQuizzes(ctx)
+			if err != nil {
+				slog.WarnContext(ctx, "Startup cache warmup incomplete", "error", err)
+			}
+			slog.InfoContext(ctx, "Warmed cache for active quizzes", "quizzes", warmed)
+		}()
+	}
 
 	// Initialize handlers
 	quizHandler := handler.NewQuizHandler(quizService)
 	wsHandler := handler.NewWebSocketHandler(wsService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	seriesHandler := handler.NewSeriesHandler(seriesService, wsService)
+	lobbyHandler := handler.NewLobbyHandler(presenceService, wsService)
+	participantHandler := handler.NewParticipantHandler(quizService, presenceService, wsService)
+	adminHandler := handler.NewAdminHandler(quizService)
+	healthHandler := handler.NewHealthHandler(healthService)
 
 	// Setup Gin router
-	r := gin.Default()
+	r := gin.New()
+
+	// Probes are registered before the middleware below, which only applies
+	// to later routes, so frequent polling stays out of logs and metrics
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	r.Use(gin.Recovery(), handler.RequestID(), handler.Tracing(), handler.RequestLogger(), handler.Metrics())
 
 	// CORS middleware
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "X-User-ID"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "X-User-ID", "Authorization", handler.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", handler.RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
 	// API routes
-	api := r.Group("/api")
+	api := r.Group("/api", handler.RequestTimeout(cfg.Timeouts.Request))
 	{
 		api.POST("/quiz", quizHandler.CreateQuiz)
 		api.GET("/quiz/:quiz_id", quizHandler.GetQuiz)
 		api.POST("/quiz/:quiz_id/join", quizHandler.JoinQuiz)
+		api.POST("/quiz/:quiz_id/resume", quizHandler.ResumeQuiz)
 		api.POST("/quiz/:quiz_id/answer", quizHandler.SubmitAnswer)
+		api.POST("/quiz/:quiz_id/attempt", quizHandler.StartAttempt)
+		api.GET("/quiz/:quiz_id/attempt", quizHandler.GetAttempt)
+		api.POST("/quiz/:quiz_id/attempt/submit", quizHandler.SubmitAttempt)
+		api.GET("/quiz/:quiz_id/attempts", quizHandler.ListAttempts)
+		api.GET("/quiz/:quiz_id/lobby", lobbyHandler.GetLobby)
+		api.DELETE("/quiz/:quiz_id/lobby", lobbyHandler.LeaveLobby)
+		api.POST("/quiz/:quiz_id/heartbeat", lobbyHandler.Heartbeat)
 		api.GET("/quiz/:quiz_id/leaderboard", quizHandler.GetLeaderboard)
+		api.GET("/quiz/:quiz_id/teams", quizHandler.GetTeams)
+		api.GET("/quiz/:quiz_id/teams/leaderboard", quizHandler.GetTeamLeaderboard)
+		api.GET("/quiz/:quiz_id/me", quizHandler.GetMyStats)
+		api.GET("/quiz/:quiz_id/review", quizHandler.GetQuizReview)
+		api.GET("/quiz/:quiz_id/results", quizHandler.GetQuizResults)
+		api.GET("/users/:user_id/history", quizHandler.GetUserHistory)
+		api.GET("/winners", quizHandler.GetRecentWinners)
+
+		api.POST("/series", seriesHandler.CreateSeries)
+		api.GET("/series/:series_id", seriesHandler.GetSeries)
+		api.POST("/series/:series_id/quizzes", seriesHandler.AddQuiz)
+		api.GET("/series/:series_id/leaderboard", seriesHandler.GetSeriesLeaderboard)
 	}
 
+	// Operator API, audited; AdminAudit runs first so rejected calls are logged too
+	admin := r.Group("/admin", handler.AdminAudit(), handler.AdminAuth(cfg.Admin.Token), handler.RequestTimeout(cfg.Timeouts.Request))
+	{
+		admin.GET("/cache/stats", adminHandler.GetCacheStats)
+		admin.GET("/cache/quizzes/:quiz_id", adminHandler.GetQuizCache)
+		admin.DELETE("/cache/quizzes/:quiz_id", adminHandler.InvalidateQuizCache)
+		admin.POST("/cache/quizzes/:quiz_id/warmup", adminHandler.WarmupQuizCache)
+		admin.POST("/cache/warmup", adminHandler.WarmupActiveQuizzes)
+
+		// Completing a quiz freezes its results and reveals the answers
+		admin.PUT("/quiz/:quiz_id/status", quizHandler.UpdateQuizStatus)
+
+		// Analytics include every question's correct answer, so they are
+		// for hosts only
+		admin.GET("/quiz/:quiz_id/analytics", analyticsHandler.GetQuizAnalytics)
+		admin.GET("/quiz/:quiz_id/analytics/questions/:question_id", analyticsHandler.GetQuestionAnalytics)
+		admin.GET("/quiz/:quiz_id/analytics/drop-off", analyticsHandler.GetDropOff)
+	}
+
+	// Prometheus scrape endpoint
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// WebSocket routes
 	r.GET("/ws/quiz/:quiz_id/leaderboard", wsHandler.HandleLeaderboardWebSocket)
+	r.GET("/ws/quiz/:quiz_id/teams", wsHandler.HandleTeamLeaderboardWebSocket)
+	r.GET("/ws/quiz/:quiz_id/lobby", lobbyHandler.HandleLobbyWebSocket)
+	r.GET("/ws/quiz/:quiz_id/participant", participantHandler.HandleParticipantWebSocket)
+	r.GET("/ws/series/:series_id/leaderboard", seriesHandler.HandleSeriesLeaderboardWebSocket)
+
+	srv := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: r,
+	}
+
+	slog.Info("Server starting", "port", cfg.Server.Port)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fatal("Server failed", "error", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop() // a second signal kills the process immediately
+
+	// Fail readiness first and keep serving for a moment, so load balancers
+	// stop sending new requests before the listener closes
+	healthService.BeginShutdown()
+	if delay := cfg.Server.DrainDelay; delay > 0 {
+		slog.Info("Server marked not ready", "drain_delay", delay)
+		time.Sleep(delay)
+	}
+
+	timeout := cfg.Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	slog.Info("Server shutting down", "drain_timeout", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Stop accepting requests and let in-flight ones (e.g. answer
+	// submissions) complete; hijacked WebSocket connections are not covered
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("HTTP drain incomplete", "error", err)
+	}
+	if err := wsService.Shutdown(shutdownCtx, "server restarting"); err != nil {
+		slog.Warn("WebSocket drain incomplete", "error", err)
+	}
+	if err := quizService.Drain(shutdownCtx); err != nil {
+		slog.Warn("Background work drain incomplete", "error", err)
+	}
+	workers.Wait()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server stopped")
+}
+
+// repositories are the stores the services run on, with the background
+// work they need until shutdown and the checks that gate readiness.
+type repositories struct {
+	quiz    repository.QuizRepository
+	redis   repository.RedisRepository
+	workers []func(context.Context)
+	checks  []service.HealthCheck
+}
+
+// newRepositories opens the configured database (Postgres or SQLite) and
+// Redis, or with storage "memory" keeps everything in process memory so the
+// server runs without either. An empty Redis address also falls back to the
+// in-memory cache, which suits single-node SQLite deployments.
+func newRepositories(cfg *config.Config, storage string) repositories {
+	switch storage {
+	case "memory":
+		slog.Warn("Using in-memory storage; data is lost on restart")
+		return repositories{quiz: repository.NewMemoryQuizRepository(), redis: repository.NewMemoryRedisRepository(cfg.Redis)}
+	case "database":
+	default:
+		fatal("Unknown storage: expected database or memory", "storage", storage)
+	}
+
+	db, driver := openDatabase(cfg)
+	migrator, err := migration.New(db, driver)
+	if err != nil {
+		fatal("Failed to load migrations", "error", err)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		fatal("Refusing to start; run `migrate up` first", "error", err)
+	}
+	repos := repositories{
+		quiz: repository.NewQuizRepository(db, cfg.Timeouts.Database),
+		checks: []service.HealthCheck{
+			{Name: driver, Check: func(ctx context.Context) error {
+				sqlDB, err := db.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.PingContext(ctx)
+			}},
+			{Name: "migrations", Check: migrator.Check},
+		},
+	}
+
+	if cfg.Redis.Addr == "" {
+		slog.Info("No Redis address configured; using the in-memory cache")
+		repos.redis = repository.NewMemoryRedisRepository(cfg.Redis)
+		return repos
+	}
+
+	// Redis connection
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	rdb.AddHook(metrics.RedisHook{})
+	rdb.AddHook(tracing.RedisHook{})
+
+	repos.checks = append(repos.checks, service.HealthCheck{Name: "redis", Check: func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}})
+
+	repos.redis = repository.NewRedisRepository(rdb, cfg.Timeouts.Redis, cfg.Redis)
+	if cfg.Redis.LocalQuizCacheSize <= 0 {
+		return repos
+	}
+
+	// Quiz definitions are read on every answer; keep hot ones in process
+	localCache := repository.NewLocalCacheRepository(repos.redis, rdb, cfg.Redis)
+	repos.redis = localCache
+	repos.workers = []func(context.Context){localCache.Run}
+	return repos
+}
+
+// openDatabase connects to the configured SQL database and returns it with
+// its driver name, which also selects the migration set.
+func openDatabase(cfg *config.Config) (*gorm.DB, string) {
+	var dialector gorm.Dialector
+	driver := cfg.Database.Driver
+	switch driver {
+	case "", "postgres":
+		driver = "postgres"
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+			cfg.Database.Host, cfg.Database.User, cfg.Database.Password,
+			cfg.Database.Name, cfg.Database.Port, cfg.Database.SSLMode,
+		)
+		dialector = postgres.Open(dsn)
+	case "sqlite":
+		// Foreign keys are off by default in SQLite; cascades rely on them.
+		dialector = sqlite.Open(cfg.Database.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	default:
+		fatal("Unknown database driver: expected postgres or sqlite", "driver", driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		fatal("Failed to connect to database", "error", err)
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		fatal("Failed to register database metrics", "error", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		fatal("Failed to register database tracing", "error", err)
+	}
+	return db, driver
+}
 
-	log.Printf("Server starting on port %s", cfg.Server.Port)
-	log.Fatal(r.Run(":" + cfg.Server.Port))
+// fatal logs msg at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
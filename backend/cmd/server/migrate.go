@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"quiz-app/internal/config"
+	"quiz-app/internal/migration"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status | force <version>"
+
+// runMigrate implements the `migrate` subcommand against the configured
+// database:
+//
+//	migrate up              apply all pending migrations
+//	migrate down [steps]    revert the latest migration, or the latest steps
+//	migrate status          show the current and expected versions
+//	migrate force <version> record the version without running any SQL
+//
+// Results are printed to stdout whatever the log level; failures are logged
+// and exit with a non-zero status.
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		usageExit()
+	}
+
+	db, driver := openDatabase(cfg)
+	migrator, err := migration.New(db, driver)
+	if err != nil {
+		fatal("Failed to load migrations", "error", err)
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fatal("Migration failed", "error", err)
+		}
+		fmt.Printf("Schema is at version %d\n", migrator.Latest())
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				usageExit()
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fatal("Migration rollback failed", "error", err)
+		}
+
+	case "status":
+		applied, err := migrator.Applied(ctx)
+		if err != nil {
+			fatal("Failed to read applied migrations", "error", err)
+		}
+		version := 0
+		for _, m := range applied {
+			fmt.Printf("%03d_%s applied at %s\n", m.Version, m.Name, m.AppliedAt.Format("2006-01-02 15:04:05"))
+			version = m.Version
+		}
+		fmt.Printf("Database is at version %d, this build expects %d\n", version, migrator.Latest())
+
+	case "force":
+		if len(args) < 2 {
+			usageExit()
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			usageExit()
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			fatal("Failed to record schema version", "error", err)
+		}
+		fmt.Printf("Recorded schema version %d\n", version)
+
+	default:
+		usageExit()
+	}
+}
+
+// usageExit prints the subcommand's usage and exits with status 2, as for any
+// other invalid invocation.
+func usageExit() {
+	fmt.Fprintln(os.Stderr, migrateUsage)
+	os.Exit(2)
+}
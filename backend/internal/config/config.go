@@ -2,27 +2,58 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   Server
-	Redis    Redis
-	Database Database
+	Server    Server
+	Redis     Redis
+	Database  Database
+	Cleanup   Cleanup
+	Scheduler Scheduler
+	Presence  Presence
+	Timeouts  Timeouts
+	Admin     Admin
+	Log       Log
+	Tracing   Tracing
 }
 
+// Server configures the HTTP listener. On SIGTERM /readyz starts failing and
+// requests are still served for DrainDelay, giving load balancers time to
+// notice; then in-flight requests, WebSocket clients and background work get
+// ShutdownTimeout to finish.
 type Server struct {
-	Port string `mapstructure:"port"`
+	Port            string        `mapstructure:"port"`
+	DrainDelay      time.Duration `mapstructure:"drain_delay"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 }
 
+// Redis configures the cache. QuizTTL and LeaderboardTTL are how long
+// entries stay fresh (an hour when unset). A non-zero StaleWhileRevalidate
+// keeps entries that long past their TTL, serving them while a background
+// load refreshes them; it costs a TTL lookup per cache hit. With
+// WarmupOnStart every active quiz is loaded into the cache at startup.
+// LocalQuizCacheSize quiz definitions are also kept in process memory for
+// up to LocalQuizCacheTTL (30s when unset); a size of 0 disables this.
 type Redis struct {
-	Addr     string `mapstructure:"addr"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
+	Addr                 string        `mapstructure:"addr"`
+	Password             string        `mapstructure:"password"`
+	DB                   int           `mapstructure:"db"`
+	WarmupOnStart        bool          `mapstructure:"warmup_on_start"`
+	QuizTTL              time.Duration `mapstructure:"quiz_ttl"`
+	LeaderboardTTL       time.Duration `mapstructure:"leaderboard_ttl"`
+	StaleWhileRevalidate time.Duration `mapstructure:"stale_while_revalidate"`
+	LocalQuizCacheSize   int           `mapstructure:"local_quiz_cache_size"`
+	LocalQuizCacheTTL    time.Duration `mapstructure:"local_quiz_cache_ttl"`
 }
 
+// Database selects the SQL backend. Driver is postgres (default) or sqlite;
+// the sqlite driver only uses Path, the database file.
 type Database struct {
+	Driver   string `mapstructure:"driver"`
+	Path     string `mapstructure:"path"`
 	Name     string `mapstructure:"name"`
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
@@ -31,6 +62,71 @@ type Database struct {
 	SSLMode  string `mapstructure:"sslmode"`
 }
 
+// Cleanup configures the background worker that expires quizzes. Completed
+// quizzes older than Retention are archived or deleted per RetentionAction
+// (none, archive, delete); a zero Retention keeps them forever.
+type Cleanup struct {
+	Interval        time.Duration `mapstructure:"interval"`
+	Retention       time.Duration `mapstructure:"retention"`
+	RetentionAction string        `mapstructure:"retention_action"`
+}
+
+// Scheduler configures automatic quiz starts. Countdowns are pushed to
+// viewers during the last CountdownWindow before a quiz opens; LockTTL bounds
+// how long one instance may hold the start lock.
+type Scheduler struct {
+	Interval        time.Duration `mapstructure:"interval"`
+	CountdownWindow time.Duration `mapstructure:"countdown_window"`
+	LockTTL         time.Duration `mapstructure:"lock_ttl"`
+}
+
+// Timeouts bound how long work may run. Request is the deadline of a whole
+// API call; Database and Redis cap each individual query. Zero disables a
+// limit.
+type Timeouts struct {
+	Request  time.Duration `mapstructure:"request"`
+	Database time.Duration `mapstructure:"database"`
+	Redis    time.Duration `mapstructure:"redis"`
+}
+
+// Presence configures the waiting room. A participant counts as online while
+// their last heartbeat is younger than HeartbeatTTL; SweepInterval is how
+// often rosters are rechecked for participants who silently went away.
+// A participant whose socket drops is only marked offline if they have not
+// reconnected within ReconnectGrace.
+type Presence struct {
+	HeartbeatTTL   time.Duration `mapstructure:"heartbeat_ttl"`
+	SweepInterval  time.Duration `mapstructure:"sweep_interval"`
+	ReconnectGrace time.Duration `mapstructure:"reconnect_grace"`
+}
+
+// Admin protects the /admin API, which callers reach with an
+// "Authorization: Bearer <Token>" header. An empty Token disables the API.
+type Admin struct {
+	Token string `mapstructure:"token"`
+}
+
+// Log configures the process logger. Level is debug, info (default), warn or
+// error; Format is text (default) or json.
+type Log struct {
+	Level  string `mapstructure:"level"`
+	Format string `mapstructure:"format"`
+}
+
+// Tracing configures OpenTelemetry. Exporter is none (default), otlp, stdout
+// or file. otlp sends spans over HTTP to Endpoint (host:port; the standard
+// OTEL_EXPORTER_OTLP_* variables apply when empty), in plain text with
+// Insecure; file writes them as JSON to File. SampleRatio is the share of new
+// traces recorded, all of them when unset.
+type Tracing struct {
+	Exporter    string  `mapstructure:"exporter"`
+	ServiceName string  `mapstructure:"service_name"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	File        string  `mapstructure:"file"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
 func LoadConfig(env string) (*Config, error) {
 	v := viper.New()
 	if env == "" {
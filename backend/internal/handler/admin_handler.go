@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"quiz-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves the operator-only /admin API for managing the cache.
+type AdminHandler struct {
+	quizService service.QuizService
+}
+
+func NewAdminHandler(quizService service.QuizService) *AdminHandler {
+	return &AdminHandler{quizService: quizService}
+}
+
+func (h *AdminHandler) GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"caches": h.quizService.CacheStats()})
+}
+
+func (h *AdminHandler) GetQuizCache(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	entries, err := h.quizService.InspectQuizCache(c.Request.Context(), quizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quiz_id": quizID, "entries": entries})
+}
+
+// InvalidateQuizCache drops a quiz's cached data. The scope query parameter
+// picks quiz (the definition), leaderboard, or all (default).
+func (h *AdminHandler) InvalidateQuizCache(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+	ctx := c.Request.Context()
+
+	var err error
+	switch scope := c.DefaultQuery("scope", "all"); scope {
+	case "quiz":
+		err = h.quizService.InvalidateQuizCache(ctx, quizID)
+	case "leaderboard":
+		err = h.quizService.InvalidateLeaderboardCache(ctx, quizID)
+	case "all":
+		err = h.quizService.PurgeQuizCache(ctx, quizID)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be quiz, leaderboard or all"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "invalidated"})
+}
+
+func (h *AdminHandler) WarmupQuizCache(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	if err := h.quizService.WarmupCache(c.Request.Context(), quizID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "warmed"})
+}
+
+func (h *AdminHandler) WarmupActiveQuizzes(c *gin.Context) {
+	warmed, err := h.quizService.WarmupActiveQuizzes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"warmed": warmed, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"warmed": warmed})
+}
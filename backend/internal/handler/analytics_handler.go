@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"quiz-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+func (h *AnalyticsHandler) GetQuizAnalytics(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	analytics, err := h.analyticsService.GetQuizAnalytics(c.Request.Context(), quizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+func (h *AnalyticsHandler) GetQuestionAnalytics(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+	questionID := c.Param("question_id")
+
+	analytics, err := h.analyticsService.GetQuestionAnalytics(c.Request.Context(), quizID, questionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+func (h *AnalyticsHandler) GetDropOff(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	analytics, err := h.analyticsService.GetQuizAnalytics(c.Request.Context(), quizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"participants": analytics.Participants,
+		"drop_off":     analytics.DropOff,
+	})
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+	"quiz-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler serves the probes used by docker-compose and orchestrators.
+type HealthHandler struct {
+	healthService service.HealthService
+}
+
+func NewHealthHandler(healthService service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Liveness reports that the process is up and serving HTTP. It checks no
+// dependencies, so an outage elsewhere does not get the server restarted.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness answers 200 when every dependency is reachable and the schema
+// is current, and 503 otherwise or once shutdown has begun.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	readiness, ready := h.healthService.Readiness(c.Request.Context())
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+	c.JSON(http.StatusOK, readiness)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"quiz-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LobbyHandler struct {
+	presenceService service.PresenceService
+	wsService       service.WebSocketService
+}
+
+func NewLobbyHandler(presenceService service.PresenceService, wsService service.WebSocketService) *LobbyHandler {
+	return &LobbyHandler{
+		presenceService: presenceService,
+		wsService:       wsService,
+	}
+}
+
+func (h *LobbyHandler) GetLobby(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	roster, err := h.presenceService.GetRoster(c.Request.Context(), quizID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"participants": roster})
+}
+
+func (h *LobbyHandler) Heartbeat(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+	userID := c.GetHeader("X-User-ID")
+
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID required"})
+		return
+	}
+
+	if err := h.presenceService.Heartbeat(c.Request.Context(), quizID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "online"})
+}
+
+func (h *LobbyHandler) LeaveLobby(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+	userID := c.GetHeader("X-User-ID")
+
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID required"})
+		return
+	}
+
+	if err := h.presenceService.Leave(c.Request.Context(), quizID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "left"})
+}
+
+// HandleLobbyWebSocket streams the lobby roster. Hosts connect without a
+// user_id; participants pass theirs and every message they send counts as
+// a heartbeat, so they stay online while the socket is open.
+func (h *LobbyHandler) HandleLobbyWebSocket(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+	userID := c.Query("user_id")
+
+	if userID != "" && !h.presenceService.IsMember(c.Request.Context(), quizID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "participant not in lobby"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	// The hooks run after this handler returns, so they must not inherit its
+	// cancellation
+	ctx := context.WithoutCancel(c.Request.Context())
+
+	release := h.presenceService.WatchLobby(quizID)
+	hooks := service.ClientHooks{OnClose: release}
+	if userID != "" {
+		hooks.OnMessage = func(message []byte) {
+			if err := h.presenceService.Heartbeat(ctx, quizID, userID); err != nil {
+				slog.WarnContext(ctx, "Lobby heartbeat rejected", "quiz_id", quizID, "user_id", userID, "error", err)
+			}
+		}
+		hooks.OnClose = func() {
+			release()
+			h.presenceService.Disconnect(ctx, quizID, userID)
+		}
+	}
+
+	h.wsService.RegisterLobbyClient(quizID, conn, hooks)
+
+	if userID != "" {
+		if err := h.presenceService.Heartbeat(ctx, quizID, userID); err != nil {
+			slog.WarnContext(ctx, "Lobby heartbeat rejected", "quiz_id", quizID, "user_id", userID, "error", err)
+		}
+	}
+	h.presenceService.BroadcastRoster(ctx, quizID, "roster", "")
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"quiz-app/internal/model"
+	"quiz-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ParticipantHandler struct {
+	quizService     service.QuizService
+	presenceService service.PresenceService
+	wsService       service.WebSocketService
+}
+
+func NewParticipantHandler(quizService service.QuizService, presenceService service.PresenceService, wsService service.WebSocketService) *ParticipantHandler {
+	return &ParticipantHandler{
+		quizService:     quizService,
+		presenceService: presenceService,
+		wsService:       wsService,
+	}
+}
+
+// HandleParticipantWebSocket is the participant's own connection. The
+// handshake carries the token from join; the first message is a "resume"
+// event with the participant's state, followed by the quiz's live updates.
+func (h *ParticipantHandler) HandleParticipantWebSocket(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	state, err := h.quizService.ResumeParticipant(c.Request.Context(), quizID, c.Query("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidParticipantToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	// Nothing else writes to the connection until it is registered
+	if err := conn.WriteJSON(model.WSMessage{Type: "resume", Data: state}); err != nil {
+		conn.Close()
+		return
+	}
+
+	// The hooks run after this handler returns, so they must not inherit its
+	// cancellation
+	ctx := context.WithoutCancel(c.Request.Context())
+	userID := state.UserID.String()
+	heartbeat := func() {
+		if h.presenceService.IsMember(ctx, quizID, userID) {
+			h.presenceService.Heartbeat(ctx, quizID, userID)
+		}
+	}
+
+	heartbeat()
+	h.wsService.RegisterParticipant(quizID, conn, service.ClientHooks{
+		OnMessage: func(message []byte) { heartbeat() },
+		OnClose:   func() { h.presenceService.Disconnect(ctx, quizID, userID) },
+	})
+}
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"quiz-app/internal/model"
 	"quiz-app/internal/service"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,7 +26,7 @@ func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 		return
 	}
 
-	quiz, err := h.quizService.CreateQuiz(&req)
+	quiz, err := h.quizService.CreateQuiz(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -45,16 +48,44 @@ func (h *QuizHandler) JoinQuiz(c *gin.Context) {
 		return
 	}
 
-	user, err := h.quizService.JoinQuiz(quizID, &req)
+	user, token, err := h.quizService.JoinQuiz(c.Request.Context(), quizID, &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"user_id":  user.ID,
 		"username": user.Username,
-	})
+		"token":    token,
+	}
+	if req.Team != "" {
+		response["team"] = req.Team
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *QuizHandler) ResumeQuiz(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	var req model.ResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := h.quizService.ResumeParticipant(c.Request.Context(), quizID, req.Token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidParticipantToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
 }
 
 func (h *QuizHandler) SubmitAnswer(c *gin.Context) {
@@ -72,7 +103,7 @@ func (h *QuizHandler) SubmitAnswer(c *gin.Context) {
 		return
 	}
 
-	result, err := h.quizService.SubmitAnswer(userID, quizID, &req)
+	result, err := h.quizService.SubmitAnswer(c.Request.Context(), userID, quizID, &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -84,7 +115,7 @@ func (h *QuizHandler) SubmitAnswer(c *gin.Context) {
 func (h *QuizHandler) GetLeaderboard(c *gin.Context) {
 	quizID := c.Param("quiz_id")
 
-	leaderboard, err := h.quizService.GetLeaderboard(quizID)
+	leaderboard, err := h.quizService.GetLeaderboard(c.Request.Context(), quizID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -96,16 +127,194 @@ func (h *QuizHandler) GetLeaderboard(c *gin.Context) {
 func (h *QuizHandler) GetQuiz(c *gin.Context) {
 	quizID := c.Param("quiz_id")
 
-	quiz, err := h.quizService.GetQuiz(quizID)
+	quiz, err := h.quizService.GetQuiz(c.Request.Context(), quizID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
 		return
 	}
 
-	// Remove correct answers from response for security
-	for i := range quiz.Questions {
-		quiz.Questions[i].CorrectAnswer = ""
+	// Remove correct answers from response for security. The quiz may be
+	// shared with other requests, so blank them on a copy.
+	public := *quiz
+	public.Questions = make([]model.Question, len(quiz.Questions))
+	for i, q := range quiz.Questions {
+		q.CorrectAnswer = ""
+		public.Questions[i] = q
+	}
+
+	c.JSON(http.StatusOK, public)
+}
+
+func (h *QuizHandler) GetMyStats(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+	userID := c.GetHeader("X-User-ID")
+
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID required"})
+		return
+	}
+
+	stats, err := h.quizService.GetParticipantStats(c.Request.Context(), userID, quizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *QuizHandler) UpdateQuizStatus(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	var req model.UpdateQuizStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quiz, err := h.quizService.UpdateQuizStatus(c.Request.Context(), quizID, req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quiz_id": quiz.ID,
+		"title":   quiz.Title,
+		"status":  quiz.Status,
+	})
+}
+
+func (h *QuizHandler) GetQuizReview(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+	userID := c.GetHeader("X-User-ID")
+
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID required"})
+		return
+	}
+
+	review, err := h.quizService.GetQuizReview(c.Request.Context(), userID, quizID)
+	if err != nil {
+		if errors.Is(err, service.ErrReviewNotAvailable) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+func (h *QuizHandler) GetQuizResults(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	results, err := h.quizService.GetQuizResults(c.Request.Context(), quizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+func (h *QuizHandler) GetUserHistory(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	history, err := h.quizService.GetUserHistory(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+func (h *QuizHandler) GetRecentWinners(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	winners, err := h.quizService.GetRecentWinners(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"winners": winners})
+}
+
+func (h *QuizHandler) GetTeams(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	teams, err := h.quizService.GetTeams(c.Request.Context(), quizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+func (h *QuizHandler) GetTeamLeaderboard(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	leaderboard, err := h.quizService.GetTeamLeaderboard(c.Request.Context(), quizID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"leaderboard": leaderboard})
+}
+
+func (h *QuizHandler) StartAttempt(c *gin.Context) {
+	h.handleAttempt(c, h.quizService.StartAttempt)
+}
+
+func (h *QuizHandler) GetAttempt(c *gin.Context) {
+	h.handleAttempt(c, h.quizService.GetAttempt)
+}
+
+func (h *QuizHandler) SubmitAttempt(c *gin.Context) {
+	h.handleAttempt(c, h.quizService.SubmitAttempt)
+}
+
+func (h *QuizHandler) ListAttempts(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+	userID := c.GetHeader("X-User-ID")
+
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID required"})
+		return
+	}
+
+	attempts, err := h.quizService.ListAttempts(c.Request.Context(), userID, quizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attempts": attempts})
+}
+
+func (h *QuizHandler) handleAttempt(c *gin.Context, action func(ctx context.Context, userID, quizID string) (*model.QuizAttempt, error)) {
+	quizID := c.Param("quiz_id")
+	userID := c.GetHeader("X-User-ID")
+
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID required"})
+		return
+	}
+
+	attempt, err := action(c.Request.Context(), userID, quizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, quiz)
+	c.JSON(http.StatusOK, attempt)
 }
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"quiz-app/internal/model"
+	"quiz-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SeriesHandler struct {
+	seriesService service.SeriesService
+	wsService     service.WebSocketService
+}
+
+func NewSeriesHandler(seriesService service.SeriesService, wsService service.WebSocketService) *SeriesHandler {
+	return &SeriesHandler{
+		seriesService: seriesService,
+		wsService:     wsService,
+	}
+}
+
+func (h *SeriesHandler) CreateSeries(c *gin.Context) {
+	var req model.CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.seriesService.CreateSeries(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+func (h *SeriesHandler) GetSeries(c *gin.Context) {
+	seriesID := c.Param("series_id")
+
+	series, err := h.seriesService.GetSeries(c.Request.Context(), seriesID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *SeriesHandler) AddQuiz(c *gin.Context) {
+	seriesID := c.Param("series_id")
+
+	var req model.AddSeriesQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.seriesService.AddQuiz(c.Request.Context(), seriesID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *SeriesHandler) GetSeriesLeaderboard(c *gin.Context) {
+	seriesID := c.Param("series_id")
+
+	leaderboard, err := h.seriesService.GetSeriesLeaderboard(c.Request.Context(), seriesID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"leaderboard": leaderboard})
+}
+
+func (h *SeriesHandler) HandleSeriesLeaderboardWebSocket(c *gin.Context) {
+	seriesID := c.Param("series_id")
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	h.wsService.RegisterSeriesViewer(seriesID, conn)
+}
@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"quiz-app/internal/service"
 
@@ -28,9 +28,21 @@ func (h *WebSocketHandler) HandleLeaderboardWebSocket(c *gin.Context) {
 
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "WebSocket upgrade failed", "error", err)
 		return
 	}
 
 	h.wsService.RegisterLeaderboardViewer(quizID, conn)
 }
+
+func (h *WebSocketHandler) HandleTeamLeaderboardWebSocket(c *gin.Context) {
+	quizID := c.Param("quiz_id")
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	h.wsService.RegisterTeamLeaderboardViewer(quizID, conn)
+}
@@ -0,0 +1,107 @@
+// Package metrics defines the Prometheus metrics served on /metrics.
+package metrics
+
+import (
+	"quiz-app/internal/model"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "quiz_http_request_duration_seconds",
+		Help:    "HTTP request latency by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	AnswersSubmitted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quiz_answers_submitted_total",
+		Help: "Answers recorded, by result (correct or incorrect).",
+	}, []string{"result"})
+
+	WebSocketViewers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "quiz_websocket_viewers",
+		Help: "WebSocket clients connected to each hub.",
+	}, []string{"hub"})
+
+	BroadcastFanoutDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "quiz_websocket_broadcast_fanout_seconds",
+		Help:    "Time for a hub to hand one message to all of its clients.",
+		Buckets: []float64{.00005, .0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1},
+	}, []string{"hub_type"})
+
+	DroppedClients = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quiz_websocket_dropped_clients_total",
+		Help: "Clients disconnected because their send buffer was full.",
+	}, []string{"hub_type"})
+
+	StoreErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quiz_store_errors_total",
+		Help: "Failed database and Redis operations, by store and operation.",
+	}, []string{"store", "operation"})
+)
+
+// HubType groups hub channels ("<quizID>", "team:<quizID>", "series:<id>",
+// "lobby:<quizID>") into a label of bounded cardinality.
+func HubType(channel string) string {
+	if kind, _, ok := strings.Cut(channel, ":"); ok {
+		return kind
+	}
+	return "quiz"
+}
+
+// RegisterActiveQuizzes exposes the number of active quizzes, counted by
+// count on every scrape.
+func RegisterActiveQuizzes(count func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "quiz_active_quizzes",
+		Help: "Quizzes currently in the active state.",
+	}, count)
+}
+
+// RegisterCacheStats exposes the cache counters reported by stats.
+func RegisterCacheStats(stats func() []model.CacheStats) {
+	prometheus.MustRegister(&cacheCollector{stats: stats})
+}
+
+var (
+	cacheRequestsDesc = prometheus.NewDesc(
+		"quiz_cache_requests_total",
+		"Cache reads by cache and result (hit, stale_hit, miss, coalesced).",
+		[]string{"cache", "result"}, nil,
+	)
+	cacheHitRatioDesc = prometheus.NewDesc(
+		"quiz_cache_hit_ratio",
+		"Share of cache reads served from the cache, stale hits included.",
+		[]string{"cache"}, nil,
+	)
+)
+
+type cacheCollector struct {
+	stats func() []model.CacheStats
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheRequestsDesc
+	ch <- cacheHitRatioDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.stats() {
+		for result, count := range map[string]uint64{
+			"hit":       s.Hits,
+			"stale_hit": s.StaleHits,
+			"miss":      s.Misses,
+			"coalesced": s.Coalesced,
+		} {
+			ch <- prometheus.MustNewConstMetric(cacheRequestsDesc, prometheus.CounterValue, float64(count), s.Name, result)
+		}
+
+		if total := s.Hits + s.StaleHits + s.Misses; total > 0 {
+			ratio := float64(s.Hits+s.StaleHits) / float64(total)
+			ch <- prometheus.MustNewConstMetric(cacheHitRatioDesc, prometheus.GaugeValue, ratio, s.Name)
+		}
+	}
+}
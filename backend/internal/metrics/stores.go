@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// GormPlugin counts failed queries in StoreErrors under the database's
+// dialect name. Not-found lookups are expected and not counted.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	for operation, register := range map[string]func(name string, fn func(*gorm.DB)) error{
+		"create": callbacks.Create().After("gorm:create").Register,
+		"query":  callbacks.Query().After("gorm:query").Register,
+		"update": callbacks.Update().After("gorm:update").Register,
+		"delete": callbacks.Delete().After("gorm:delete").Register,
+		"row":    callbacks.Row().After("gorm:row").Register,
+		"raw":    callbacks.Raw().After("gorm:raw").Register,
+	} {
+		operation := operation
+		if err := register("metrics:after_"+operation, func(tx *gorm.DB) {
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				StoreErrors.WithLabelValues(tx.Dialector.Name(), operation).Inc()
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedisHook counts failed Redis commands in StoreErrors. redis.Nil only
+// means a key was missing and is not counted.
+type RedisHook struct{}
+
+func (RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	countRedisError(cmd)
+	return nil
+}
+
+func (RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		countRedisError(cmd)
+	}
+	return nil
+}
+
+func countRedisError(cmd redis.Cmder) {
+	if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+		StoreErrors.WithLabelValues("redis", cmd.Name()).Inc()
+	}
+}
@@ -0,0 +1,261 @@
+// Package migration applies the versioned SQL files from the migrations
+// package and records them in a schema_migrations table.
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-app/migrations"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaMismatch is returned by Check when the database is not at the
+// version this build expects.
+var ErrSchemaMismatch = errors.New("database schema version mismatch")
+
+// Migration is one numbered schema change with its up and down SQL.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// AppliedMigration is a row of schema_migrations.
+type AppliedMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (AppliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New loads the migrations for driver (postgres or sqlite).
+func New(db *gorm.DB, driver string) (*Migrator, error) {
+	loaded, err := load(migrations.FS, driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: loaded}, nil
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q: %w", dir, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, f := range files {
+		name := f.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		prefix, rest, ok := strings.Cut(strings.TrimSuffix(name, "."+direction+".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: rest}
+			byVersion[version] = m
+		} else if m.Name != rest {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, rest)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	sorted := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		sorted = append(sorted, *m)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be contiguous from 1: expected %d, found %d", i+1, m.Version)
+		}
+	}
+	return sorted, nil
+}
+
+// Latest is the version the embedded migrations bring the schema to.
+func (m *Migrator) Latest() int {
+	return len(m.migrations)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(`
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            name VARCHAR(255) NOT NULL,
+            applied_at TIMESTAMP NOT NULL
+        )`).Error
+}
+
+// Applied lists the recorded migrations, oldest first.
+func (m *Migrator) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var applied []AppliedMigration
+	err := m.db.WithContext(ctx).Order("version").Find(&applied).Error
+	return applied, err
+}
+
+// Version is the highest applied migration, 0 for an empty database.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// recordedVersion reads the highest applied migration without creating
+// schema_migrations, so that probing a database never changes it.
+func (m *Migrator) recordedVersion(ctx context.Context) (int, error) {
+	db := m.db.WithContext(ctx)
+	var version int
+	err := db.Model(&AppliedMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	if err != nil && !db.Migrator().HasTable(&AppliedMigration{}) {
+		return 0, fmt.Errorf("%w: schema_migrations table does not exist", ErrSchemaMismatch)
+	}
+	return version, err
+}
+
+// Check fails with ErrSchemaMismatch unless the database is exactly at Latest.
+// It only reads, so it is safe to run on every readiness probe.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.recordedVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version != m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, this build expects %d", ErrSchemaMismatch, version, m.Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version > m.Latest() {
+		return nil, fmt.Errorf("%w: database is at version %d, newer than this build's %d", ErrSchemaMismatch, version, m.Latest())
+	}
+	if version == 0 && m.db.WithContext(ctx).Migrator().HasTable("users") {
+		return nil, fmt.Errorf("database has tables but no recorded migrations; run `migrate force <version>` to record the version it is at")
+	}
+
+	var applied []Migration
+	for _, mig := range m.migrations[version:] {
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&AppliedMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+		applied = append(applied, mig)
+	}
+	return applied, nil
+}
+
+// Down reverts the latest steps migrations and returns the ones it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version > m.Latest() {
+		return nil, fmt.Errorf("%w: database is at version %d, newer than this build's %d", ErrSchemaMismatch, version, m.Latest())
+	}
+
+	var reverted []Migration
+	for ; steps > 0 && version > 0; steps, version = steps-1, version-1 {
+		mig := m.migrations[version-1]
+		if mig.Down == "" {
+			return reverted, fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+		}
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&AppliedMigration{}, mig.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("reverting migration %d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+		reverted = append(reverted, mig)
+	}
+	return reverted, nil
+}
+
+// Force records the database as being at version without running any SQL,
+// e.g. to adopt a schema created before migrations were tracked.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version < 0 || version > m.Latest() {
+		return fmt.Errorf("version must be between 0 and %d", m.Latest())
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&AppliedMigration{}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, mig := range m.migrations[:version] {
+			if err := tx.Create(&AppliedMigration{Version: mig.Version, Name: mig.Name, AppliedAt: now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
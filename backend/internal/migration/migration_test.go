@@ -0,0 +1,79 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newSQLiteMigrator(t *testing.T) (*Migrator, *gorm.DB) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quiz.db")
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	m, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	return m, db
+}
+
+func TestCheckDoesNotCreateSchemaMigrations(t *testing.T) {
+	ctx := context.Background()
+	m, db := newSQLiteMigrator(t)
+
+	if err := m.Check(ctx); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("check on empty database = %v, want ErrSchemaMismatch", err)
+	}
+	if db.Migrator().HasTable(&AppliedMigration{}) {
+		t.Fatal("check created schema_migrations")
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("check after up: %v", err)
+	}
+
+	if _, err := m.Down(ctx, 1); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("check after down = %v, want ErrSchemaMismatch", err)
+	}
+}
+
+func TestReviewVisibilityRenamedToAfterAnswer(t *testing.T) {
+	ctx := context.Background()
+	m, db := newSQLiteMigrator(t)
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if _, err := m.Down(ctx, 1); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	err := db.Exec("INSERT INTO quiz_sessions (id, title, review_visibility) VALUES ('q1', 'Old', 'after_question')").Error
+	if err != nil {
+		t.Fatalf("insert quiz: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	var visibility string
+	if err := db.Raw("SELECT review_visibility FROM quiz_sessions WHERE id = 'q1'").Scan(&visibility).Error; err != nil {
+		t.Fatalf("read quiz: %v", err)
+	}
+	if visibility != "after_answer" {
+		t.Fatalf("review_visibility = %q, want after_answer", visibility)
+	}
+}
@@ -1,6 +1,10 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,51 +12,220 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid"`
 	Username  string    `json:"username" gorm:"unique;not null"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 type QuizSession struct {
-	ID        uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	Title     string     `json:"title" gorm:"not null"`
-	Status    string     `json:"status" gorm:"default:'waiting'"` // waiting, active, completed
-	Questions []Question `json:"questions" gorm:"foreignKey:QuizSessionID"`
-	CreatedAt time.Time  `json:"created_at"`
-	ExpiresAt time.Time  `json:"expires_at"`
+	ID               uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid"`
+	Title            string     `json:"title" gorm:"not null"`
+	Status           string     `json:"status" gorm:"default:'waiting'"`               // waiting, active, completed, archived
+	ReviewVisibility string     `json:"review_visibility" gorm:"default:'after_quiz'"` // never, after_answer, after_quiz
+	SeriesID         *uuid.UUID `json:"series_id,omitempty" gorm:"type:uuid;index"`
+	TeamMode         string     `json:"team_mode" gorm:"default:'none'"`      // none, predefined, self_select
+	TeamScoring      string     `json:"team_scoring" gorm:"default:'sum'"`    // sum, average
+	Mode             string     `json:"mode" gorm:"default:'live'"`           // live, self_paced
+	TimeLimitSeconds int        `json:"time_limit_seconds"`                   // per-participant budget in self_paced mode
+	MaxAttempts      int        `json:"max_attempts" gorm:"default:1"`        // self_paced only
+	ScoringPolicy    string     `json:"scoring_policy" gorm:"default:'best'"` // best, latest, average
+	Questions        []Question `json:"questions" gorm:"foreignKey:QuizSessionID"`
+	Teams            []Team     `json:"teams,omitempty" gorm:"foreignKey:QuizSessionID"`
+	StartsAt         *time.Time `json:"starts_at,omitempty" gorm:"index"` // opens automatically when set
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"` // set when the quiz completes
+}
+
+type Team struct {
+	ID            uuid.UUID    `json:"id" gorm:"primaryKey;type:uuid"`
+	QuizSessionID uuid.UUID    `json:"quiz_id" gorm:"type:uuid;not null;uniqueIndex:idx_teams_quiz_name"`
+	Name          string       `json:"name" gorm:"not null;uniqueIndex:idx_teams_quiz_name"`
+	Members       []TeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// TeamMember assigns a user to one team within a quiz session.
+type TeamMember struct {
+	QuizSessionID uuid.UUID `json:"quiz_id" gorm:"primaryKey;type:uuid"`
+	UserID        uuid.UUID `json:"user_id" gorm:"primaryKey;type:uuid"`
+	TeamID        uuid.UUID `json:"team_id" gorm:"type:uuid;not null;index"`
+	JoinedAt      time.Time `json:"joined_at"`
+}
+
+// ParticipantScore is a participant's running total in a quiz, updated in
+// the same transaction as each answer so it never lags behind them. In
+// self-paced quizzes Attempts carries the per-attempt scores as of that
+// transaction.
+type ParticipantScore struct {
+	QuizSessionID uuid.UUID      `json:"quiz_id" gorm:"primaryKey;type:uuid"`
+	UserID        uuid.UUID      `json:"user_id" gorm:"primaryKey;type:uuid"`
+	Score         int            `json:"score" gorm:"not null;default:0"`
+	Answers       int            `json:"answers" gorm:"not null;default:0"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Attempts      []AttemptScore `json:"attempts,omitempty" gorm:"-"`
+}
+
+// QuizAttempt tracks one participant's run through a self-paced quiz. The
+// attempt ends when they submit it or when Deadline passes. Participants may
+// retry up to the quiz's MaxAttempts; Number counts from 1.
+type QuizAttempt struct {
+	ID               uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid"`
+	QuizSessionID    uuid.UUID  `json:"quiz_id" gorm:"type:uuid;not null;uniqueIndex:idx_quiz_attempts_quiz_user_number"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_quiz_attempts_quiz_user_number"`
+	Number           int        `json:"number" gorm:"not null;default:1;uniqueIndex:idx_quiz_attempts_quiz_user_number"`
+	Status           string     `json:"status" gorm:"default:'in_progress';index"` // in_progress, submitted, timed_out
+	StartedAt        time.Time  `json:"started_at"`
+	Deadline         time.Time  `json:"deadline" gorm:"index"`
+	EndedAt          *time.Time `json:"ended_at,omitempty"`
+	RemainingSeconds int        `json:"remaining_seconds" gorm:"-"`
+	Score            int        `json:"score" gorm:"-"`
+}
+
+// AttemptScore is the score earned within a single attempt.
+type AttemptScore struct {
+	AttemptID uuid.UUID `json:"attempt_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Number    int       `json:"number"`
+	Status    string    `json:"status"`
+	Score     int       `json:"score"`
+}
+
+// Series groups independent quiz sessions (e.g. weekly quizzes) under one
+// cumulative leaderboard.
+type Series struct {
+	ID          uuid.UUID     `json:"id" gorm:"primaryKey;type:uuid"`
+	Title       string        `json:"title" gorm:"not null"`
+	Aggregation string        `json:"aggregation" gorm:"default:'sum'"` // sum, best_n, average
+	BestN       int           `json:"best_n"`
+	Quizzes     []QuizSession `json:"quizzes,omitempty" gorm:"foreignKey:SeriesID"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// StringList is stored as a JSON array in a text column so that it works on
+// every supported database, not only Postgres with its text[] type.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	return string(data), err
+}
+
+// Scan also accepts Postgres array literals ({a,b}) so rows that were written
+// before migration 011 converted the column still read correctly.
+func (l *StringList) Scan(src interface{}) error {
+	var data string
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", src)
+	}
+
+	if strings.HasPrefix(data, "{") {
+		var arr pq.StringArray
+		if err := arr.Scan(data); err != nil {
+			return err
+		}
+		*l = StringList(arr)
+		return nil
+	}
+	return json.Unmarshal([]byte(data), (*[]string)(l))
 }
 
 type Question struct {
-	ID            uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	QuizSessionID uuid.UUID      `json:"quiz_session_id"`
-	QuestionText  string         `json:"question_text" gorm:"not null"`
-	Options       pq.StringArray `json:"options" gorm:"type:text[]"`
-	CorrectAnswer string         `json:"correct_answer" gorm:"not null"`
-	Points        int            `json:"points" gorm:"default:10"`
-	Order         int            `json:"order"`
+	ID            uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid"`
+	QuizSessionID uuid.UUID  `json:"quiz_session_id"`
+	QuestionText  string     `json:"question_text" gorm:"not null"`
+	Options       StringList `json:"options" gorm:"type:text"`
+	CorrectAnswer string     `json:"correct_answer" gorm:"not null"`
+	Points        int        `json:"points" gorm:"default:10"`
+	Order         int        `json:"order"`
 }
 
 type UserAnswer struct {
-	ID         uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid"`
-	QuestionID uuid.UUID `json:"question_id" gorm:"type:uuid"`
+	ID             uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;uniqueIndex:idx_user_answers_user_question_live,where:attempt_id IS NULL"`
+	QuestionID     uuid.UUID  `json:"question_id" gorm:"type:uuid;uniqueIndex:idx_user_answers_user_question_live;uniqueIndex:idx_user_answers_attempt_question,priority:2"`
+	AttemptID      *uuid.UUID `json:"attempt_id,omitempty" gorm:"type:uuid;index;uniqueIndex:idx_user_answers_attempt_question,priority:1,where:attempt_id IS NOT NULL"` // set in self_paced mode
+	Answer         string     `json:"answer"`
+	IsCorrect      bool       `json:"is_correct"`
+	ResponseTimeMs int        `json:"response_time_ms" gorm:"default:0"`
+	AnsweredAt     time.Time  `json:"answered_at"`
+}
+
+// AnswerCount is how many answers a question received for a given option.
+type AnswerCount struct {
+	QuestionID uuid.UUID `json:"question_id"`
 	Answer     string    `json:"answer"`
-	IsCorrect  bool      `json:"is_correct"`
-	AnsweredAt time.Time `json:"answered_at"`
+	Count      int       `json:"count"`
 }
 
-type Participant struct {
-	UserID   uuid.UUID `json:"user_id"`
+// QuizResult is a participant's final standing, frozen when the quiz completes.
+type QuizResult struct {
+	ID            uuid.UUID `json:"id" gorm:"primaryKey;type:uuid"`
+	QuizSessionID uuid.UUID `json:"quiz_id" gorm:"type:uuid;not null;uniqueIndex:idx_quiz_results_quiz_user"`
+	UserID        uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_quiz_results_quiz_user"`
+	Username      string    `json:"username"`
+	Rank          int       `json:"rank"`
+	Score         int       `json:"score"`
+	CompletedAt   time.Time `json:"completed_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// UserQuizResult is one entry in a user's cross-quiz history.
+type UserQuizResult struct {
+	QuizID       uuid.UUID `json:"quiz_id"`
+	Title        string    `json:"title"`
+	UserID       uuid.UUID `json:"user_id"`
+	Username     string    `json:"username"`
+	Rank         int       `json:"rank"`
+	Score        int       `json:"score"`
+	Participants int       `json:"participants"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
+// SeriesScore is a user's score in one quiz of a series.
+type SeriesScore struct {
 	QuizID   uuid.UUID `json:"quiz_id"`
+	UserID   uuid.UUID `json:"user_id"`
 	Username string    `json:"username"`
 	Score    int       `json:"score"`
-	JoinedAt time.Time `json:"joined_at"`
+}
+
+type Participant struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	QuizID   uuid.UUID  `json:"quiz_id"`
+	Username string     `json:"username"`
+	TeamID   *uuid.UUID `json:"team_id,omitempty"`
+	TeamName string     `json:"team_name,omitempty"`
+	Score    int        `json:"score"`
+	JoinedAt time.Time  `json:"joined_at"`
 }
 
 // Request/Response DTOs
 type CreateQuizRequest struct {
-	Title     string            `json:"title" binding:"required"`
-	Questions []QuestionRequest `json:"questions" binding:"required,min=1"`
+	Title            string            `json:"title" binding:"required,max=100"`
+	ReviewVisibility string            `json:"review_visibility" binding:"omitempty,oneof=never after_answer after_quiz"`
+	SeriesID         string            `json:"series_id" binding:"omitempty,uuid"`
+	TeamMode         string            `json:"team_mode" binding:"omitempty,oneof=none predefined self_select"`
+	TeamScoring      string            `json:"team_scoring" binding:"omitempty,oneof=sum average"`
+	Mode             string            `json:"mode" binding:"omitempty,oneof=live self_paced"`
+	TimeLimitSeconds int               `json:"time_limit_seconds" binding:"min=0"`
+	MaxAttempts      int               `json:"max_attempts" binding:"min=0"`
+	ScoringPolicy    string            `json:"scoring_policy" binding:"omitempty,oneof=best latest average"`
+	StartsAt         *time.Time        `json:"starts_at"`
+	ExpiresAt        *time.Time        `json:"expires_at"`
+	Teams            []string          `json:"teams" binding:"dive,required,max=50"`
+	Questions        []QuestionRequest `json:"questions" binding:"required,min=1"`
 }
 
 type QuestionRequest struct {
@@ -62,13 +235,29 @@ type QuestionRequest struct {
 	Points        int      `json:"points"`
 }
 
+type CreateSeriesRequest struct {
+	Title       string `json:"title" binding:"required,max=100"`
+	Aggregation string `json:"aggregation" binding:"omitempty,oneof=sum best_n average"`
+	BestN       int    `json:"best_n" binding:"min=0"`
+}
+
+type AddSeriesQuizRequest struct {
+	QuizID string `json:"quiz_id" binding:"required,uuid"`
+}
+
 type JoinQuizRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required,max=50"`
+	Team     string `json:"team"`
+}
+
+type UpdateQuizStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active completed"`
 }
 
 type SubmitAnswerRequest struct {
-	QuestionID string `json:"question_id" binding:"required"`
-	Answer     string `json:"answer" binding:"required"`
+	QuestionID     string `json:"question_id" binding:"required"`
+	Answer         string `json:"answer" binding:"required"`
+	ResponseTimeMs int    `json:"response_time_ms" binding:"min=0"`
 }
 
 type SubmitAnswerResponse struct {
@@ -77,6 +266,14 @@ type SubmitAnswerResponse struct {
 	Points   int  `json:"points"`
 }
 
+type TeamLeaderboardEntry struct {
+	TeamID   uuid.UUID `json:"team_id"`
+	TeamName string    `json:"team_name"`
+	Members  int       `json:"members"`
+	Score    int       `json:"score"`
+	Rank     int       `json:"rank"`
+}
+
 type LeaderboardEntry struct {
 	UserID   uuid.UUID `json:"user_id"`
 	Username string    `json:"username"`
@@ -84,14 +281,206 @@ type LeaderboardEntry struct {
 	Rank     int       `json:"rank"`
 }
 
+// QuestionResult is one question as seen by a single participant.
+// Correct is only set once the question has closed for them.
+type QuestionResult struct {
+	QuestionID     uuid.UUID `json:"question_id"`
+	Order          int       `json:"order"`
+	Answered       bool      `json:"answered"`
+	Answer         string    `json:"answer,omitempty"`
+	Correct        *bool     `json:"correct,omitempty"`
+	ResponseTimeMs int       `json:"response_time_ms,omitempty"`
+}
+
+type ParticipantStats struct {
+	UserID                uuid.UUID        `json:"user_id"`
+	QuizID                uuid.UUID        `json:"quiz_id"`
+	Username              string           `json:"username"`
+	Rank                  int              `json:"rank"`
+	Score                 int              `json:"score"`
+	AnsweredQuestions     int              `json:"answered_questions"`
+	UnansweredQuestions   int              `json:"unanswered_questions"`
+	AverageResponseTimeMs float64          `json:"average_response_time_ms"`
+	Questions             []QuestionResult `json:"questions"`
+}
+
+type OptionStat struct {
+	Option     string  `json:"option"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// QuestionReview reveals a question after it has closed. CorrectAnswer and
+// OptionStats stay empty for questions that are still open.
+type QuestionReview struct {
+	QuestionID    uuid.UUID    `json:"question_id"`
+	Order         int          `json:"order"`
+	QuestionText  string       `json:"question_text"`
+	Options       []string     `json:"options"`
+	Points        int          `json:"points"`
+	Revealed      bool         `json:"revealed"`
+	CorrectAnswer string       `json:"correct_answer,omitempty"`
+	YourAnswer    string       `json:"your_answer,omitempty"`
+	Correct       *bool        `json:"correct,omitempty"`
+	TotalAnswers  int          `json:"total_answers"`
+	OptionStats   []OptionStat `json:"option_stats,omitempty"`
+}
+
+type QuizReview struct {
+	QuizID           uuid.UUID        `json:"quiz_id"`
+	Title            string           `json:"title"`
+	Status           string           `json:"status"`
+	ReviewVisibility string           `json:"review_visibility"`
+	Questions        []QuestionReview `json:"questions"`
+}
+
+// QuestionAnalytics describes how a question performed across all
+// participants. DiscriminationIndex is the correct rate of the top 27% of
+// participants minus that of the bottom 27%.
+type QuestionAnalytics struct {
+	QuestionID           uuid.UUID    `json:"question_id"`
+	Order                int          `json:"order"`
+	QuestionText         string       `json:"question_text"`
+	CorrectAnswer        string       `json:"correct_answer"`
+	Responses            int          `json:"responses"`
+	CorrectCount         int          `json:"correct_count"`
+	CorrectRate          float64      `json:"correct_rate"`
+	MedianResponseTimeMs float64      `json:"median_response_time_ms"`
+	DiscriminationIndex  float64      `json:"discrimination_index"`
+	Options              []OptionStat `json:"options"`
+}
+
+// DropOffPoint counts participants who stopped answering after a question.
+type DropOffPoint struct {
+	QuestionID   uuid.UUID `json:"question_id"`
+	Order        int       `json:"order"`
+	Reached      int       `json:"reached"`
+	DroppedAfter int       `json:"dropped_after"`
+}
+
+type QuizAnalytics struct {
+	QuizID       uuid.UUID           `json:"quiz_id"`
+	Title        string              `json:"title"`
+	Participants int                 `json:"participants"`
+	Questions    []QuestionAnalytics `json:"questions"`
+	DropOff      []DropOffPoint      `json:"drop_off"`
+}
+
 // WebSocket Messages
 type WSMessage struct {
 	Type string `json:"type"`
 	Data any    `json:"data"`
 }
 
+type QuizCountdown struct {
+	QuizID           uuid.UUID `json:"quiz_id"`
+	StartsAt         time.Time `json:"starts_at"`
+	SecondsRemaining int       `json:"seconds_remaining"`
+}
+
+type LobbyParticipant struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	Username string     `json:"username"`
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+}
+
+// LobbyUpdate carries the full waiting-room roster along with the event that
+// changed it: joined, left, online, offline or roster.
+type LobbyUpdate struct {
+	Type         string             `json:"type"`
+	Event        string             `json:"event"`
+	UserID       *uuid.UUID         `json:"user_id,omitempty"`
+	Participants []LobbyParticipant `json:"participants"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+type ResumeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResumeQuestion is a question as shown to a participant, without its answer.
+type ResumeQuestion struct {
+	ID           uuid.UUID `json:"id"`
+	Order        int       `json:"order"`
+	QuestionText string    `json:"question_text"`
+	Options      []string  `json:"options"`
+	Points       int       `json:"points"`
+}
+
+type SubmittedAnswer struct {
+	QuestionID uuid.UUID `json:"question_id"`
+	Answer     string    `json:"answer"`
+	AnsweredAt time.Time `json:"answered_at"`
+}
+
+// ResumeState is everything a reconnecting participant needs to pick up
+// where they left off. RemainingSeconds is the time left to answer: until
+// the attempt deadline in self_paced mode, otherwise until the quiz expires.
+type ResumeState struct {
+	QuizID           uuid.UUID         `json:"quiz_id"`
+	UserID           uuid.UUID         `json:"user_id"`
+	Username         string            `json:"username"`
+	Status           string            `json:"status"`
+	Mode             string            `json:"mode"`
+	CurrentQuestion  *ResumeQuestion   `json:"current_question,omitempty"`
+	TotalQuestions   int               `json:"total_questions"`
+	Answers          []SubmittedAnswer `json:"answers"`
+	Score            int               `json:"score"`
+	RemainingSeconds int               `json:"remaining_seconds"`
+	StartsAt         *time.Time        `json:"starts_at,omitempty"`
+	Attempt          *QuizAttempt      `json:"attempt,omitempty"`
+}
+
 type LeaderboardUpdate struct {
 	Type        string             `json:"type"`
 	Leaderboard []LeaderboardEntry `json:"leaderboard"`
 	UpdatedAt   time.Time          `json:"updated_at"`
 }
+
+type TeamLeaderboardUpdate struct {
+	Type        string                 `json:"type"`
+	Leaderboard []TeamLeaderboardEntry `json:"leaderboard"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+type SeriesLeaderboardUpdate struct {
+	Type        string             `json:"type"`
+	SeriesID    uuid.UUID          `json:"series_id"`
+	Leaderboard []LeaderboardEntry `json:"leaderboard"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// CacheEntry describes one Redis key held for a quiz. TTLSeconds is -1 for
+// a key without expiry and omitted when the key does not exist.
+type CacheEntry struct {
+	Key        string `json:"key"`
+	Exists     bool   `json:"exists"`
+	TTLSeconds *int64 `json:"ttl_seconds,omitempty"`
+}
+
+// CacheStats counts reads of one cache since the server started. StaleHits
+// were served while being refreshed; Coalesced misses waited on another
+// caller's load instead of querying the database themselves.
+type CacheStats struct {
+	Name      string `json:"name"`
+	Hits      uint64 `json:"hits"`
+	StaleHits uint64 `json:"stale_hits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"`
+}
+
+// Readiness reports whether the server can take traffic: Status is "ready",
+// "not_ready" when a dependency check failed, or "shutting_down".
+type Readiness struct {
+	Status string            `json:"status"`
+	Checks []DependencyCheck `json:"checks,omitempty"`
+}
+
+// DependencyCheck is the outcome of probing one dependency for readiness.
+type DependencyCheck struct {
+	Name      string `json:"name"`
+	Healthy   bool   `json:"healthy"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Names and titles are bounded by their column sizes, so over-long input is
+// rejected before it reaches any storage backend.
+func TestRequestsRejectOverlongNames(t *testing.T) {
+	questions := []QuestionRequest{{QuestionText: "q", Options: []string{"a", "b"}, CorrectAnswer: "a"}}
+	cases := []struct {
+		name string
+		req  interface{}
+		ok   bool
+	}{
+		{"username at limit", &JoinQuizRequest{Username: strings.Repeat("é", 50)}, true},
+		{"username too long", &JoinQuizRequest{Username: strings.Repeat("a", 51)}, false},
+		{"quiz title at limit", &CreateQuizRequest{Title: strings.Repeat("a", 100), Questions: questions}, true},
+		{"quiz title too long", &CreateQuizRequest{Title: strings.Repeat("a", 101), Questions: questions}, false},
+		{"team name too long", &CreateQuizRequest{Title: "Teams", Teams: []string{strings.Repeat("a", 51)}, Questions: questions}, false},
+		{"series title too long", &CreateSeriesRequest{Title: strings.Repeat("a", 101)}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(tc.req)
+			if (err == nil) != tc.ok {
+				t.Fatalf("validate = %v, want ok = %v", err, tc.ok)
+			}
+		})
+	}
+}
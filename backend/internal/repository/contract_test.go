@@ -0,0 +1,659 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"quiz-app/internal/config"
+	"quiz-app/internal/migration"
+	"quiz-app/internal/model"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The contract tests run against every backend of each repository interface,
+// so the in-memory stores cannot drift from the SQL and Redis ones. Postgres
+// and Redis take part only when these name a server the tests may write to:
+//
+//	QUIZ_TEST_POSTGRES_DSN="host=localhost user=quiz_user password=quiz_password dbname=quiz_test sslmode=disable"
+//	QUIZ_TEST_REDIS_ADDR=localhost:6379
+//
+// Every test works on fresh IDs, so data left by earlier runs does no harm.
+const (
+	postgresDSNEnv = "QUIZ_TEST_POSTGRES_DSN"
+	redisAddrEnv   = "QUIZ_TEST_REDIS_ADDR"
+)
+
+type quizRepoBackend struct {
+	name string
+	open func(t *testing.T) QuizRepository
+}
+
+var quizRepoBackends = []quizRepoBackend{
+	{"memory", func(*testing.T) QuizRepository { return NewMemoryQuizRepository() }},
+	{"sqlite", func(t *testing.T) QuizRepository {
+		path := filepath.Join(t.TempDir(), "quiz.db")
+		return openMigrated(t, sqlite.Open(path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), "sqlite")
+	}},
+	{"postgres", func(t *testing.T) QuizRepository {
+		dsn := os.Getenv(postgresDSNEnv)
+		if dsn == "" {
+			t.Skipf("%s not set", postgresDSNEnv)
+		}
+		return openMigrated(t, postgres.Open(dsn), "postgres")
+	}},
+}
+
+// openMigrated opens a database as the server does and brings its schema up
+// to date.
+func openMigrated(t *testing.T, dialector gorm.Dialector, driver string) QuizRepository {
+	t.Helper()
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open %s: %v", driver, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := migration.New(db, driver)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate %s: %v", driver, err)
+	}
+	return NewQuizRepository(db, 5*time.Second)
+}
+
+type redisRepoBackend struct {
+	name string
+	open func(t *testing.T) RedisRepository
+}
+
+var redisRepoBackends = []redisRepoBackend{
+	{"memory", func(*testing.T) RedisRepository { return NewMemoryRedisRepository(config.Redis{}) }},
+	{"redis", func(t *testing.T) RedisRepository {
+		return NewRedisRepository(openRedis(t), time.Second, config.Redis{})
+	}},
+	{"redis+lru", func(t *testing.T) RedisRepository {
+		client := openRedis(t)
+		cfg := config.Redis{LocalQuizCacheSize: 100, LocalQuizCacheTTL: time.Minute}
+		return NewLocalCacheRepository(NewRedisRepository(client, time.Second, cfg), client, cfg)
+	}},
+}
+
+func openRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skipf("%s not set", redisAddrEnv)
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("ping %s: %v", addr, err)
+	}
+	return client
+}
+
+func TestQuizRepositoryContract(t *testing.T) {
+	for _, backend := range quizRepoBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, c := range quizRepoContract {
+				t.Run(c.name, func(t *testing.T) {
+					c.run(t, context.Background(), backend.open(t))
+				})
+			}
+		})
+	}
+}
+
+func TestRedisRepositoryContract(t *testing.T) {
+	for _, backend := range redisRepoBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, c := range redisRepoContract {
+				t.Run(c.name, func(t *testing.T) {
+					c.run(t, context.Background(), backend.open(t))
+				})
+			}
+		})
+	}
+}
+
+// uniqueName returns prefix made unique across runs sharing a database while
+// keeping names with different prefixes in the same order.
+func uniqueName(prefix string) string {
+	return prefix + "-" + uuid.NewString()[:8]
+}
+
+func createQuiz(t *testing.T, ctx context.Context, repo QuizRepository, quiz *model.QuizSession) {
+	t.Helper()
+	if quiz.ID == uuid.Nil {
+		quiz.ID = uuid.New()
+	}
+	if quiz.Title == "" {
+		quiz.Title = "Contract"
+	}
+	if quiz.Status == "" {
+		quiz.Status = "waiting"
+	}
+	if quiz.CreatedAt.IsZero() {
+		quiz.CreatedAt = time.Now()
+	}
+	if quiz.ExpiresAt.IsZero() {
+		quiz.ExpiresAt = time.Now().Add(time.Hour)
+	}
+	if err := repo.CreateQuiz(ctx, quiz); err != nil {
+		t.Fatalf("create quiz: %v", err)
+	}
+}
+
+func createUser(t *testing.T, ctx context.Context, repo QuizRepository, username string) *model.User {
+	t.Helper()
+	user := &model.User{ID: uuid.New(), Username: username, CreatedAt: time.Now()}
+	if err := repo.CreateUser(ctx, user); err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	return user
+}
+
+func recordAnswer(ctx context.Context, repo QuizRepository, quizID, userID, questionID uuid.UUID, points int) (*model.ParticipantScore, error) {
+	return repo.RecordAnswer(ctx, quizID, &model.UserAnswer{
+		ID:         uuid.New(),
+		UserID:     userID,
+		QuestionID: questionID,
+		Answer:     "a",
+		IsCorrect:  points > 0,
+		AnsweredAt: time.Now(),
+	}, points)
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+var quizRepoContract = []struct {
+	name string
+	run  func(t *testing.T, ctx context.Context, repo QuizRepository)
+}{
+	{"quiz round trip keeps question and team order", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		quiz := &model.QuizSession{
+			Questions: []model.Question{
+				{ID: uuid.New(), QuestionText: "third", Options: []string{"x", "y"}, CorrectAnswer: "x", Points: 5, Order: 2},
+				{ID: uuid.New(), QuestionText: "first", Options: []string{"a, b", "c"}, CorrectAnswer: "c", Points: 10, Order: 0},
+				{ID: uuid.New(), QuestionText: "second", Options: []string{"1", "2", "3"}, CorrectAnswer: "2", Points: 20, Order: 1},
+			},
+			Teams: []model.Team{
+				{ID: uuid.New(), Name: "Red", CreatedAt: time.Now()},
+				{ID: uuid.New(), Name: "Blue", CreatedAt: time.Now()},
+			},
+		}
+		createQuiz(t, ctx, repo, quiz)
+
+		got, err := repo.GetQuiz(ctx, quiz.ID)
+		if err != nil {
+			t.Fatalf("get quiz: %v", err)
+		}
+		var texts []string
+		for _, q := range got.Questions {
+			texts = append(texts, q.QuestionText)
+		}
+		if len(texts) != 3 || texts[0] != "first" || texts[1] != "second" || texts[2] != "third" {
+			t.Fatalf("questions = %v, want first, second, third", texts)
+		}
+		if options := got.Questions[0].Options; len(options) != 2 || options[0] != "a, b" || options[1] != "c" {
+			t.Fatalf("options = %q, want [\"a, b\" \"c\"]", options)
+		}
+		if len(got.Teams) != 2 || got.Teams[0].Name != "Blue" || got.Teams[1].Name != "Red" {
+			t.Fatalf("teams = %+v, want Blue then Red", got.Teams)
+		}
+	}},
+
+	{"missing quiz is not found", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		if _, err := repo.GetQuiz(ctx, uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("get missing quiz = %v, want ErrRecordNotFound", err)
+		}
+		if err := repo.UpdateQuizStatus(ctx, uuid.New(), "active"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("update missing quiz = %v, want ErrRecordNotFound", err)
+		}
+	}},
+
+	{"scheduled quiz starts once", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		startsAt := time.Now().Add(-time.Minute)
+		quiz := &model.QuizSession{StartsAt: &startsAt}
+		createQuiz(t, ctx, repo, quiz)
+
+		scheduled, err := repo.GetScheduledQuizzes(ctx, time.Now())
+		if err != nil {
+			t.Fatalf("get scheduled: %v", err)
+		}
+		var ids []uuid.UUID
+		for _, q := range scheduled {
+			ids = append(ids, q.ID)
+		}
+		if !containsID(ids, quiz.ID) {
+			t.Fatal("due quiz not scheduled")
+		}
+
+		for i, want := range []bool{true, false} {
+			started, err := repo.StartScheduledQuiz(ctx, quiz.ID)
+			if err != nil || started != want {
+				t.Fatalf("start #%d = %v, %v; want %v", i+1, started, err, want)
+			}
+		}
+		ids, err = repo.GetQuizIDsByStatus(ctx, "active")
+		if err != nil || !containsID(ids, quiz.ID) {
+			t.Fatalf("active quizzes = %v, %v; want to contain %s", ids, err, quiz.ID)
+		}
+	}},
+
+	{"only unfinished quizzes expire", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		past := time.Now().Add(-time.Minute)
+		expired := &model.QuizSession{Status: "active", ExpiresAt: past}
+		completed := &model.QuizSession{Status: "completed", ExpiresAt: past}
+		current := &model.QuizSession{Status: "active"}
+		for _, quiz := range []*model.QuizSession{expired, completed, current} {
+			createQuiz(t, ctx, repo, quiz)
+		}
+
+		quizzes, err := repo.GetExpiredQuizzes(ctx, time.Now())
+		if err != nil {
+			t.Fatalf("get expired: %v", err)
+		}
+		var ids []uuid.UUID
+		for _, q := range quizzes {
+			ids = append(ids, q.ID)
+		}
+		if !containsID(ids, expired.ID) || containsID(ids, completed.ID) || containsID(ids, current.ID) {
+			t.Fatalf("expired = %v, want only %s of ours", ids, expired.ID)
+		}
+
+		if err := repo.ArchiveQuizzes(ctx, []uuid.UUID{completed.ID}); err != nil {
+			t.Fatalf("archive: %v", err)
+		}
+		got, err := repo.GetQuiz(ctx, completed.ID)
+		if err != nil || got.Status != "archived" {
+			t.Fatalf("archived quiz status = %q, %v", got.Status, err)
+		}
+	}},
+
+	{"retention counts from completion", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		// Expired days ago but only just completed by the cleanup worker
+		quiz := &model.QuizSession{Status: "active", ExpiresAt: time.Now().Add(-72 * time.Hour)}
+		createQuiz(t, ctx, repo, quiz)
+		if err := repo.UpdateQuizStatus(ctx, quiz.ID, "completed"); err != nil {
+			t.Fatalf("complete quiz: %v", err)
+		}
+
+		completed := []string{"completed"}
+		ids, err := repo.GetQuizIDsCompletedBefore(ctx, completed, time.Now().Add(-time.Hour))
+		if err != nil || containsID(ids, quiz.ID) {
+			t.Fatalf("completed an hour ago = %v, %v; want without %s", ids, err, quiz.ID)
+		}
+		ids, err = repo.GetQuizIDsCompletedBefore(ctx, completed, time.Now().Add(time.Minute))
+		if err != nil || !containsID(ids, quiz.ID) {
+			t.Fatalf("completed before now = %v, %v; want to contain %s", ids, err, quiz.ID)
+		}
+
+		if err := repo.ArchiveQuizzes(ctx, []uuid.UUID{quiz.ID}); err != nil {
+			t.Fatalf("archive: %v", err)
+		}
+		ids, err = repo.GetQuizIDsCompletedBefore(ctx, completed, time.Now().Add(time.Minute))
+		if err != nil || containsID(ids, quiz.ID) {
+			t.Fatalf("completed only = %v, %v; want without archived %s", ids, err, quiz.ID)
+		}
+		ids, err = repo.GetQuizIDsCompletedBefore(ctx, []string{"completed", "archived"}, time.Now().Add(time.Minute))
+		if err != nil || !containsID(ids, quiz.ID) {
+			t.Fatalf("completed or archived = %v, %v; want to contain %s", ids, err, quiz.ID)
+		}
+	}},
+
+	{"usernames are unique", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		user := createUser(t, ctx, repo, uniqueName("alice"))
+		if err := repo.CreateUser(ctx, &model.User{ID: uuid.New(), Username: user.Username, CreatedAt: time.Now()}); err == nil {
+			t.Fatal("duplicate username accepted")
+		}
+		got, err := repo.GetUserByUsername(ctx, user.Username)
+		if err != nil || got.ID != user.ID {
+			t.Fatalf("get by username = %+v, %v; want %s", got, err, user.ID)
+		}
+	}},
+
+	{"answers accumulate once per question", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		quiz := &model.QuizSession{Status: "active", Questions: []model.Question{
+			{ID: uuid.New(), QuestionText: "q1", Options: []string{"a"}, CorrectAnswer: "a", Points: 10, Order: 0},
+			{ID: uuid.New(), QuestionText: "q2", Options: []string{"a"}, CorrectAnswer: "a", Points: 5, Order: 1},
+		}}
+		createQuiz(t, ctx, repo, quiz)
+		user := createUser(t, ctx, repo, uniqueName("alice"))
+
+		score, err := recordAnswer(ctx, repo, quiz.ID, user.ID, quiz.Questions[0].ID, 10)
+		if err != nil || score.Score != 10 || score.Answers != 1 {
+			t.Fatalf("first answer = %+v, %v; want score 10 after 1 answer", score, err)
+		}
+		score, err = recordAnswer(ctx, repo, quiz.ID, user.ID, quiz.Questions[1].ID, 5)
+		if err != nil || score.Score != 15 || score.Answers != 2 {
+			t.Fatalf("second answer = %+v, %v; want score 15 after 2 answers", score, err)
+		}
+		if _, err := recordAnswer(ctx, repo, quiz.ID, user.ID, quiz.Questions[0].ID, 10); err == nil {
+			t.Fatal("second answer to the same question accepted")
+		}
+
+		if total, err := repo.GetUserScore(ctx, user.ID, quiz.ID); err != nil || total != 15 {
+			t.Fatalf("user score = %d, %v; want 15", total, err)
+		}
+		answers, err := repo.GetUserAnswers(ctx, user.ID, quiz.ID)
+		if err != nil || len(answers) != 2 || answers[0].QuestionID != quiz.Questions[0].ID {
+			t.Fatalf("user answers = %+v, %v; want both, oldest first", answers, err)
+		}
+	}},
+
+	{"participants rank by score then username", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		quiz := &model.QuizSession{Status: "active", Questions: []model.Question{
+			{ID: uuid.New(), QuestionText: "q1", Options: []string{"a"}, CorrectAnswer: "a", Points: 20},
+		}}
+		createQuiz(t, ctx, repo, quiz)
+
+		suffix := uniqueName("")
+		points := map[string]int{"carol": 10, "bob": 20, "alice": 20}
+		for _, name := range []string{"carol", "bob", "alice"} {
+			user := createUser(t, ctx, repo, name+suffix)
+			if _, err := recordAnswer(ctx, repo, quiz.ID, user.ID, quiz.Questions[0].ID, points[name]); err != nil {
+				t.Fatalf("record answer: %v", err)
+			}
+		}
+
+		participants, err := repo.GetParticipants(ctx, quiz.ID)
+		if err != nil {
+			t.Fatalf("get participants: %v", err)
+		}
+		want := []string{"alice" + suffix, "bob" + suffix, "carol" + suffix}
+		if len(participants) != len(want) {
+			t.Fatalf("participants = %+v, want %v", participants, want)
+		}
+		for i, p := range participants {
+			if p.Username != want[i] || p.QuizID != quiz.ID {
+				t.Fatalf("participants = %+v, want %v", participants, want)
+			}
+		}
+	}},
+
+	{"frozen results are replaced and ordered by rank", func(t *testing.T, ctx context.Context, repo QuizRepository) {
+		quiz := &model.QuizSession{Status: "completed"}
+		createQuiz(t, ctx, repo, quiz)
+		alice := createUser(t, ctx, repo, uniqueName("alice"))
+		bob := createUser(t, ctx, repo, uniqueName("bob"))
+
+		result := func(user *model.User, rank, score int) model.QuizResult {
+			return model.QuizResult{ID: uuid.New(), QuizSessionID: quiz.ID, UserID: user.ID, Username: user.Username, Rank: rank, Score: score, CompletedAt: time.Now(), CreatedAt: time.Now()}
+		}
+		if err := repo.SaveQuizResults(ctx, quiz.ID, []model.QuizResult{result(alice, 1, 30), result(bob, 2, 20)}); err != nil {
+			t.Fatalf("save results: %v", err)
+		}
+		if err := repo.SaveQuizResults(ctx, quiz.ID, []model.QuizResult{result(alice, 2, 30), result(bob, 1, 40)}); err != nil {
+			t.Fatalf("save results again: %v", err)
+		}
+
+		results, err := repo.GetQuizResults(ctx, quiz.ID)
+		if err != nil || len(results) != 2 || results[0].UserID != bob.ID || results[1].UserID != alice.ID {
+			t.Fatalf("results = %+v, %v; want bob then alice", results, err)
+		}
+	}},
+}
+
+// Fixed IDs make the order of tied sorted-set members predictable: Redis
+// orders them by member, which starts with the ID.
+var (
+	lowID  = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	midID  = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	highID = uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	topID  = uuid.MustParse("00000000-0000-0000-0000-000000000004")
+)
+
+func leaderboardNames(entries []model.LeaderboardEntry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Username)
+	}
+	return names
+}
+
+// checkLeaderboard fails unless entries are named want in order and ranked
+// one by one from 1, ties included.
+func checkLeaderboard(t *testing.T, entries []model.LeaderboardEntry, want ...string) {
+	t.Helper()
+	names := leaderboardNames(entries)
+	if len(names) != len(want) {
+		t.Fatalf("leaderboard = %v, want %v", names, want)
+	}
+	for i, e := range entries {
+		if e.Username != want[i] || e.Rank != i+1 {
+			t.Fatalf("leaderboard = %+v, want %v ranked from 1", entries, want)
+		}
+	}
+}
+
+var redisRepoContract = []struct {
+	name string
+	run  func(t *testing.T, ctx context.Context, repo RedisRepository)
+}{
+	{"quiz session round trip", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		quizID := uuid.New()
+		if _, err := repo.GetQuizSession(ctx, quizID.String()); !errors.Is(err, redis.Nil) {
+			t.Fatalf("get missing session = %v, want redis.Nil", err)
+		}
+
+		session := &model.QuizSession{ID: quizID, Title: "Cached", Status: "active", Questions: []model.Question{
+			{ID: uuid.New(), QuestionText: "q1", Options: []string{"a", "b"}, CorrectAnswer: "b", Points: 10},
+		}}
+		if err := repo.SetQuizSession(ctx, quizID.String(), session); err != nil {
+			t.Fatalf("set session: %v", err)
+		}
+		got, err := repo.GetQuizSession(ctx, quizID.String())
+		if err != nil || got.Title != "Cached" || len(got.Questions) != 1 || got.Questions[0].CorrectAnswer != "b" {
+			t.Fatalf("get session = %+v, %v", got, err)
+		}
+
+		if err := repo.DeleteKey(ctx, "quiz:"+quizID.String()); err != nil {
+			t.Fatalf("delete session: %v", err)
+		}
+		if _, err := repo.GetQuizSession(ctx, quizID.String()); !errors.Is(err, redis.Nil) {
+			t.Fatalf("get deleted session = %v, want redis.Nil", err)
+		}
+	}},
+
+	{"leaderboard orders by score with ties by member", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		quizID := uuid.NewString()
+		if entries, err := repo.GetLeaderboard(ctx, quizID); err != nil || len(entries) != 0 {
+			t.Fatalf("empty leaderboard = %+v, %v", entries, err)
+		}
+
+		err := repo.UpdateLeaderboard(ctx, quizID, []model.Participant{
+			{UserID: lowID, Username: "low", Score: 20},
+			{UserID: topID, Username: "top", Score: 5},
+			{UserID: highID, Username: "high", Score: 20},
+			{UserID: midID, Username: "mid", Score: 30},
+		})
+		if err != nil {
+			t.Fatalf("update leaderboard: %v", err)
+		}
+		entries, err := repo.GetLeaderboard(ctx, quizID)
+		if err != nil {
+			t.Fatalf("get leaderboard: %v", err)
+		}
+		checkLeaderboard(t, entries, "mid", "high", "low", "top")
+		if entries[0].UserID != midID || entries[0].Score != 30 {
+			t.Fatalf("leader = %+v, want mid with 30", entries[0])
+		}
+
+		// An update replaces the standings rather than merging into them
+		if err := repo.UpdateLeaderboard(ctx, quizID, []model.Participant{{UserID: lowID, Username: "low", Score: 50}}); err != nil {
+			t.Fatalf("replace leaderboard: %v", err)
+		}
+		entries, err = repo.GetLeaderboard(ctx, quizID)
+		if err != nil {
+			t.Fatalf("get leaderboard: %v", err)
+		}
+		checkLeaderboard(t, entries, "low")
+	}},
+
+	{"series leaderboard orders like the quiz leaderboard", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		seriesID := uuid.NewString()
+		err := repo.UpdateSeriesLeaderboard(ctx, seriesID, []model.LeaderboardEntry{
+			{UserID: lowID, Username: "low", Score: 7},
+			{UserID: highID, Username: "high", Score: 7},
+			{UserID: midID, Username: "mid", Score: 9},
+		})
+		if err != nil {
+			t.Fatalf("update series leaderboard: %v", err)
+		}
+		entries, err := repo.GetSeriesLeaderboard(ctx, seriesID)
+		if err != nil {
+			t.Fatalf("get series leaderboard: %v", err)
+		}
+		checkLeaderboard(t, entries, "mid", "high", "low")
+	}},
+
+	{"team leaderboard keeps names and member counts", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		quizID := uuid.NewString()
+		err := repo.UpdateTeamLeaderboard(ctx, quizID, []model.TeamLeaderboardEntry{
+			{TeamID: lowID, TeamName: "Blue", Members: 2, Score: 40},
+			{TeamID: highID, TeamName: "Red: the sequel", Members: 3, Score: 40},
+			{TeamID: midID, TeamName: "Green", Members: 1, Score: 10},
+		})
+		if err != nil {
+			t.Fatalf("update team leaderboard: %v", err)
+		}
+		entries, err := repo.GetTeamLeaderboard(ctx, quizID)
+		if err != nil {
+			t.Fatalf("get team leaderboard: %v", err)
+		}
+		want := []model.TeamLeaderboardEntry{
+			{TeamID: highID, TeamName: "Red: the sequel", Members: 3, Score: 40, Rank: 1},
+			{TeamID: lowID, TeamName: "Blue", Members: 2, Score: 40, Rank: 2},
+			{TeamID: midID, TeamName: "Green", Members: 1, Score: 10, Rank: 3},
+		}
+		if len(entries) != len(want) {
+			t.Fatalf("team leaderboard = %+v, want %+v", entries, want)
+		}
+		for i := range want {
+			if entries[i] != want[i] {
+				t.Fatalf("team leaderboard = %+v, want %+v", entries, want)
+			}
+		}
+	}},
+
+	{"keys expire after their ttl", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		key := "contract:" + uuid.NewString()
+		if ttl, err := repo.GetTTL(ctx, key); err != nil || ttl >= 0 {
+			t.Fatalf("ttl of missing key = %v, %v; want negative", ttl, err)
+		}
+		if err := repo.SetWithTTL(ctx, key, map[string]int{"n": 1}, time.Minute); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+		if exists, err := repo.KeyExists(ctx, key); err != nil || !exists {
+			t.Fatalf("exists = %v, %v; want true", exists, err)
+		}
+		if ttl, err := repo.GetTTL(ctx, key); err != nil || ttl <= 0 || ttl > time.Minute {
+			t.Fatalf("ttl = %v, %v; want within a minute", ttl, err)
+		}
+		if err := repo.DeleteKey(ctx, key); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		if exists, err := repo.KeyExists(ctx, key); err != nil || exists {
+			t.Fatalf("exists after delete = %v, %v; want false", exists, err)
+		}
+	}},
+
+	{"lobby tracks who is waiting", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		quizID := uuid.NewString()
+		for userID, username := range map[string]string{"u1": "alice", "u2": "bob"} {
+			if err := repo.AddLobbyParticipant(ctx, quizID, userID, username); err != nil {
+				t.Fatalf("add to lobby: %v", err)
+			}
+		}
+		if err := repo.RemoveLobbyParticipant(ctx, quizID, "u1"); err != nil {
+			t.Fatalf("remove from lobby: %v", err)
+		}
+		lobby, err := repo.GetLobbyParticipants(ctx, quizID)
+		if err != nil || len(lobby) != 1 || lobby["u2"] != "bob" {
+			t.Fatalf("lobby = %v, %v; want only bob", lobby, err)
+		}
+	}},
+
+	{"presence reports whether a user was online", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		quizID := uuid.NewString()
+		for i, want := range []bool{false, true} {
+			wasOnline, err := repo.TouchPresence(ctx, quizID, "u1", time.Minute)
+			if err != nil || wasOnline != want {
+				t.Fatalf("touch #%d = %v, %v; want %v", i+1, wasOnline, err, want)
+			}
+		}
+		presence, err := repo.GetPresence(ctx, quizID)
+		if seen, ok := presence["u1"]; err != nil || !ok || time.Since(seen) > time.Minute {
+			t.Fatalf("presence = %v, %v; want u1 seen just now", presence, err)
+		}
+		if err := repo.ClearPresence(ctx, quizID, "u1"); err != nil {
+			t.Fatalf("clear presence: %v", err)
+		}
+		if presence, err := repo.GetPresence(ctx, quizID); err != nil || len(presence) != 0 {
+			t.Fatalf("presence after clear = %v, %v; want empty", presence, err)
+		}
+	}},
+
+	{"participant tokens resolve to their quiz and user", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		token := uuid.NewString()
+		if _, _, err := repo.GetParticipantToken(ctx, token); err == nil {
+			t.Fatal("unknown token resolved")
+		}
+		quizID, userID := uuid.NewString(), uuid.NewString()
+		if err := repo.SaveParticipantToken(ctx, token, quizID, userID, time.Minute); err != nil {
+			t.Fatalf("save token: %v", err)
+		}
+		gotQuiz, gotUser, err := repo.GetParticipantToken(ctx, token)
+		if err != nil || gotQuiz != quizID || gotUser != userID {
+			t.Fatalf("token = %s, %s, %v; want %s, %s", gotQuiz, gotUser, err, quizID, userID)
+		}
+	}},
+
+	{"locks are released only by their holder", func(t *testing.T, ctx context.Context, repo RedisRepository) {
+		key := "lock:contract:" + uuid.NewString()
+		token, acquired, err := repo.AcquireLock(ctx, key, time.Minute)
+		if err != nil || !acquired || token == "" {
+			t.Fatalf("acquire = %q, %v, %v; want a token", token, acquired, err)
+		}
+		if _, acquired, err := repo.AcquireLock(ctx, key, time.Minute); err != nil || acquired {
+			t.Fatalf("acquire while held = %v, %v; want false", acquired, err)
+		}
+
+		if err := repo.ReleaseLock(ctx, key, "someone-else"); err != nil {
+			t.Fatalf("release with another token: %v", err)
+		}
+		if _, acquired, err := repo.AcquireLock(ctx, key, time.Minute); err != nil || acquired {
+			t.Fatalf("acquire after foreign release = %v, %v; want false", acquired, err)
+		}
+
+		if err := repo.ReleaseLock(ctx, key, token); err != nil {
+			t.Fatalf("release: %v", err)
+		}
+		if _, acquired, err := repo.AcquireLock(ctx, key, time.Minute); err != nil || !acquired {
+			t.Fatalf("acquire after release = %v, %v; want true", acquired, err)
+		}
+	}},
+}
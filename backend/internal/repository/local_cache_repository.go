@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"quiz-app/internal/config"
+	"quiz-app/internal/logging"
+	"quiz-app/internal/model"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// invalidationChannel carries an invalidation message to every server
+// instance whenever a cached quiz definition changes.
+const invalidationChannel = "cache_invalidation:quiz"
+
+// invalidation names the quiz to evict, or "*" after a flush, along with the
+// ID of the request that caused it so receivers can log it.
+type invalidation struct {
+	QuizID    string `json:"quiz_id"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// LocalCacheRepository keeps recently read quiz definitions in process
+// memory in front of Redis, sparing the answer path a Redis round trip and
+// JSON decode. Deleting a quiz key evicts it here and, via Redis pub/sub, on
+// every other instance; entries also expire after a TTL in case a message
+// is missed while reconnecting. Everything else passes through to Redis.
+type LocalCacheRepository struct {
+	RedisRepository
+	client  *redis.Client
+	quizzes *lru[*model.QuizSession]
+}
+
+func NewLocalCacheRepository(next RedisRepository, client *redis.Client, cfg config.Redis) *LocalCacheRepository {
+	ttl := cfg.LocalQuizCacheTTL
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &LocalCacheRepository{
+		RedisRepository: next,
+		client:          client,
+		quizzes:         newLRU[*model.QuizSession](cfg.LocalQuizCacheSize, ttl),
+	}
+}
+
+// GetQuizSession returns the local copy when there is one. The result is
+// shared between callers and must not be modified.
+func (r *LocalCacheRepository) GetQuizSession(ctx context.Context, quizID string) (*model.QuizSession, error) {
+	if quiz, ok := r.quizzes.get(quizID); ok {
+		return quiz, nil
+	}
+
+	version := r.quizzes.version()
+	quiz, err := r.RedisRepository.GetQuizSession(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	r.quizzes.putIfCurrent(quizID, quiz, version)
+	return quiz, nil
+}
+
+// DeleteKey evicts a quiz locally only after Redis no longer holds it, so a
+// concurrent read cannot put the old definition back.
+func (r *LocalCacheRepository) DeleteKey(ctx context.Context, key string) error {
+	err := r.RedisRepository.DeleteKey(ctx, key)
+	if quizID, ok := strings.CutPrefix(key, "quiz:"); ok {
+		r.quizzes.remove(quizID)
+		if err == nil {
+			err = r.publish(ctx, quizID)
+		}
+	}
+	return err
+}
+
+func (r *LocalCacheRepository) FlushCache(ctx context.Context) error {
+	err := r.RedisRepository.FlushCache(ctx)
+	r.quizzes.clear()
+	if err == nil {
+		err = r.publish(ctx, "*")
+	}
+	return err
+}
+
+func (r *LocalCacheRepository) publish(ctx context.Context, quizID string) error {
+	message, err := json.Marshal(invalidation{QuizID: quizID, RequestID: logging.RequestID(ctx)})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache invalidation: %w", err)
+	}
+	if err := r.client.Publish(ctx, invalidationChannel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish cache invalidation: %w", err)
+	}
+	return nil
+}
+
+// Run applies invalidations published by other instances until ctx is done.
+func (r *LocalCacheRepository) Run(ctx context.Context) {
+	pubsub := r.client.Subscribe(ctx, invalidationChannel)
+	defer pubsub.Close()
+
+	slog.InfoContext(ctx, "Listening for quiz cache invalidations")
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				slog.WarnContext(ctx, "Ignoring malformed cache invalidation", "payload", msg.Payload, "error", err)
+				continue
+			}
+			if inv.QuizID == "*" {
+				r.quizzes.clear()
+			} else {
+				r.quizzes.remove(inv.QuizID)
+			}
+			slog.DebugContext(logging.WithRequestID(ctx, inv.RequestID), "Applied cache invalidation", "quiz_id", inv.QuizID)
+		}
+	}
+}
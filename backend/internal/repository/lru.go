@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru is a fixed-size, least-recently-used map whose entries also expire
+// after ttl. It is safe for concurrent use.
+type lru[V any] struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+
+	// removals counts remove and clear calls, so that a value read before a
+	// removal is not stored after it.
+	removals uint64
+}
+
+type lruEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+func newLRU[V any](size int, ttl time.Duration) *lru[V] {
+	return &lru[V]{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *lru[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*lruEntry[V])
+	if c.ttl > 0 && !time.Now().Before(entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// version returns a token that putIfCurrent compares against.
+func (c *lru[V]) version() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.removals
+}
+
+// putIfCurrent stores value unless anything was removed since version was
+// taken. Skipping an unrelated key's value only costs a later miss.
+func (c *lru[V]) putIfCurrent(key string, value V, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.removals == version {
+		c.store(key, value)
+	}
+}
+
+func (c *lru[V]) store(key string, value V) {
+	entry := &lruEntry[V]{key: key, value: value, expiresAt: time.Now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+func (c *lru[V]) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removals++
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+func (c *lru[V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removals++
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRU[int](2, time.Minute)
+	c.putIfCurrent("a", 1, c.version())
+	c.putIfCurrent("b", 2, c.version())
+	c.get("a")
+	c.putIfCurrent("c", 3, c.version())
+
+	if _, ok := c.get("b"); ok {
+		t.Fatal("b was not evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.get(key); !ok || got != want {
+			t.Fatalf("get(%q) = %d, %v; want %d", key, got, ok, want)
+		}
+	}
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	c := newLRU[int](2, 10*time.Millisecond)
+	c.putIfCurrent("a", 1, c.version())
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.get("a"); ok {
+		t.Fatal("expired entry returned")
+	}
+}
+
+func TestLRUSkipsValueReadBeforeRemoval(t *testing.T) {
+	c := newLRU[int](2, time.Minute)
+	version := c.version()
+	c.remove("a")
+	c.putIfCurrent("a", 1, version)
+
+	if _, ok := c.get("a"); ok {
+		t.Fatal("value read before a removal was stored")
+	}
+}
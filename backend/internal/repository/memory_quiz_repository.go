@@ -0,0 +1,764 @@
+package repository
+
+import (
+	"context"
+	"quiz-app/internal/model"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memberKey identifies a participant within a quiz.
+type memberKey struct {
+	quizID uuid.UUID
+	userID uuid.UUID
+}
+
+// memoryQuizRepository implements QuizRepository in process memory for
+// tests and demos. It mirrors the Postgres schema's semantics: generated IDs
+// and timestamps, unique constraints (reported as gorm.ErrDuplicatedKey),
+// gorm.ErrRecordNotFound for missing rows and cascading quiz deletion.
+// Everything is returned by value, so callers never share its state.
+type memoryQuizRepository struct {
+	mu        sync.RWMutex
+	quizzes   map[uuid.UUID]model.QuizSession // without Questions and Teams
+	questions map[uuid.UUID]model.Question
+	users     map[uuid.UUID]model.User
+	answers   []model.UserAnswer // in insertion order
+	scores    map[memberKey]model.ParticipantScore
+	results   map[uuid.UUID][]model.QuizResult
+	series    map[uuid.UUID]model.Series
+	teams     map[uuid.UUID]model.Team
+	members   map[memberKey]model.TeamMember
+	attempts  map[uuid.UUID]model.QuizAttempt
+}
+
+func NewMemoryQuizRepository() QuizRepository {
+	return &memoryQuizRepository{
+		quizzes:   make(map[uuid.UUID]model.QuizSession),
+		questions: make(map[uuid.UUID]model.Question),
+		users:     make(map[uuid.UUID]model.User),
+		scores:    make(map[memberKey]model.ParticipantScore),
+		results:   make(map[uuid.UUID][]model.QuizResult),
+		series:    make(map[uuid.UUID]model.Series),
+		teams:     make(map[uuid.UUID]model.Team),
+		members:   make(map[memberKey]model.TeamMember),
+		attempts:  make(map[uuid.UUID]model.QuizAttempt),
+	}
+}
+
+func newID(id *uuid.UUID) {
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+}
+
+func stamp(t *time.Time) {
+	if t.IsZero() {
+		*t = time.Now()
+	}
+}
+
+func (r *memoryQuizRepository) CreateQuiz(ctx context.Context, quiz *model.QuizSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newID(&quiz.ID)
+	if _, exists := r.quizzes[quiz.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	stamp(&quiz.CreatedAt)
+
+	for i := range quiz.Questions {
+		q := &quiz.Questions[i]
+		newID(&q.ID)
+		q.QuizSessionID = quiz.ID
+		q.Options = append([]string(nil), q.Options...)
+		r.questions[q.ID] = *q
+	}
+	for i := range quiz.Teams {
+		t := &quiz.Teams[i]
+		newID(&t.ID)
+		t.QuizSessionID = quiz.ID
+		stamp(&t.CreatedAt)
+		t.Members = nil
+		r.teams[t.ID] = *t
+	}
+
+	stored := *quiz
+	stored.Questions = nil
+	stored.Teams = nil
+	r.quizzes[quiz.ID] = stored
+	return nil
+}
+
+func (r *memoryQuizRepository) GetQuiz(ctx context.Context, id uuid.UUID) (*model.QuizSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quiz, ok := r.quizzes[id]
+	if !ok {
+		return &model.QuizSession{}, gorm.ErrRecordNotFound
+	}
+
+	for _, q := range r.questions {
+		if q.QuizSessionID == id {
+			q.Options = append([]string(nil), q.Options...)
+			quiz.Questions = append(quiz.Questions, q)
+		}
+	}
+	sort.Slice(quiz.Questions, func(i, j int) bool {
+		return quiz.Questions[i].Order < quiz.Questions[j].Order
+	})
+
+	for _, t := range r.teams {
+		if t.QuizSessionID == id {
+			quiz.Teams = append(quiz.Teams, t)
+		}
+	}
+	sort.Slice(quiz.Teams, func(i, j int) bool {
+		return quiz.Teams[i].Name < quiz.Teams[j].Name
+	})
+
+	return &quiz, nil
+}
+
+func (r *memoryQuizRepository) UpdateQuizStatus(ctx context.Context, id uuid.UUID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	quiz, ok := r.quizzes[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	quiz.Status = status
+	if status == "completed" {
+		now := time.Now()
+		quiz.CompletedAt = &now
+	}
+	r.quizzes[id] = quiz
+	return nil
+}
+
+// findQuizzes returns the quizzes matching keep, without associations.
+func (r *memoryQuizRepository) findQuizzes(keep func(q model.QuizSession) bool) []model.QuizSession {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var quizzes []model.QuizSession
+	for _, q := range r.quizzes {
+		if keep(q) {
+			quizzes = append(quizzes, q)
+		}
+	}
+	sort.Slice(quizzes, func(i, j int) bool {
+		return quizzes[i].CreatedAt.Before(quizzes[j].CreatedAt)
+	})
+	return quizzes
+}
+
+func (r *memoryQuizRepository) GetExpiredQuizzes(ctx context.Context, now time.Time) ([]model.QuizSession, error) {
+	return r.findQuizzes(func(q model.QuizSession) bool {
+		return (q.Status == "waiting" || q.Status == "active") && q.ExpiresAt.Before(now)
+	}), nil
+}
+
+func (r *memoryQuizRepository) GetScheduledQuizzes(ctx context.Context, before time.Time) ([]model.QuizSession, error) {
+	quizzes := r.findQuizzes(func(q model.QuizSession) bool {
+		return q.Status == "waiting" && q.StartsAt != nil && !q.StartsAt.After(before)
+	})
+	sort.SliceStable(quizzes, func(i, j int) bool {
+		return quizzes[i].StartsAt.Before(*quizzes[j].StartsAt)
+	})
+	return quizzes, nil
+}
+
+func (r *memoryQuizRepository) StartScheduledQuiz(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	quiz, ok := r.quizzes[id]
+	if !ok || quiz.Status != "waiting" {
+		return false, nil
+	}
+	quiz.Status = "active"
+	r.quizzes[id] = quiz
+	return true, nil
+}
+
+func (r *memoryQuizRepository) GetQuizIDsCompletedBefore(ctx context.Context, statuses []string, cutoff time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, q := range r.findQuizzes(func(q model.QuizSession) bool {
+		return slices.Contains(statuses, q.Status) && q.CompletedAt != nil && q.CompletedAt.Before(cutoff)
+	}) {
+		ids = append(ids, q.ID)
+	}
+	return ids, nil
+}
+
+func (r *memoryQuizRepository) GetQuizIDsByStatus(ctx context.Context, status string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, q := range r.findQuizzes(func(q model.QuizSession) bool {
+		return q.Status == status
+	}) {
+		ids = append(ids, q.ID)
+	}
+	return ids, nil
+}
+
+func (r *memoryQuizRepository) ArchiveQuizzes(ctx context.Context, ids []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		if quiz, ok := r.quizzes[id]; ok {
+			quiz.Status = "archived"
+			r.quizzes[id] = quiz
+		}
+	}
+	return nil
+}
+
+func (r *memoryQuizRepository) DeleteQuizzes(ctx context.Context, ids []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	deletedQuestions := make(map[uuid.UUID]bool)
+	for id, q := range r.questions {
+		if deleted[q.QuizSessionID] {
+			deletedQuestions[id] = true
+			delete(r.questions, id)
+		}
+	}
+
+	answers := r.answers[:0]
+	for _, a := range r.answers {
+		if !deletedQuestions[a.QuestionID] {
+			answers = append(answers, a)
+		}
+	}
+	r.answers = answers
+
+	for id, a := range r.attempts {
+		if deleted[a.QuizSessionID] {
+			delete(r.attempts, id)
+		}
+	}
+	for key := range r.scores {
+		if deleted[key.quizID] {
+			delete(r.scores, key)
+		}
+	}
+	for key := range r.members {
+		if deleted[key.quizID] {
+			delete(r.members, key)
+		}
+	}
+	for id, t := range r.teams {
+		if deleted[t.QuizSessionID] {
+			delete(r.teams, id)
+		}
+	}
+	for id := range deleted {
+		delete(r.results, id)
+		delete(r.quizzes, id)
+	}
+	return nil
+}
+
+func (r *memoryQuizRepository) CreateUser(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newID(&user.ID)
+	for _, u := range r.users {
+		if u.ID == user.ID || u.Username == user.Username {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	stamp(&user.CreatedAt)
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryQuizRepository) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return &model.User{}, gorm.ErrRecordNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryQuizRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return &model.User{}, gorm.ErrRecordNotFound
+}
+
+// RecordAnswer holds the write lock for the whole update, which gives the
+// same all-or-nothing, one-at-a-time behaviour as the Postgres transaction.
+func (r *memoryQuizRepository) RecordAnswer(ctx context.Context, quizID uuid.UUID, answer *model.UserAnswer, points int) (*model.ParticipantScore, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newID(&answer.ID)
+	for _, a := range r.answers {
+		sameSlot := a.UserID == answer.UserID && a.QuestionID == answer.QuestionID && a.AttemptID == nil && answer.AttemptID == nil
+		sameAttempt := a.AttemptID != nil && answer.AttemptID != nil && *a.AttemptID == *answer.AttemptID && a.QuestionID == answer.QuestionID
+		if a.ID == answer.ID || sameSlot || sameAttempt {
+			return nil, gorm.ErrDuplicatedKey
+		}
+	}
+	r.answers = append(r.answers, *answer)
+
+	key := memberKey{quizID: quizID, userID: answer.UserID}
+	score := r.scores[key]
+	score.QuizSessionID = quizID
+	score.UserID = answer.UserID
+	score.Score += points
+	score.Answers++
+	score.UpdatedAt = answer.AnsweredAt
+	r.scores[key] = score
+
+	if answer.AttemptID != nil {
+		score.Attempts = r.attemptScores(quizID, &answer.UserID)
+	}
+	return &score, nil
+}
+
+func (r *memoryQuizRepository) GetUserScore(ctx context.Context, userID, quizID uuid.UUID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.scores[memberKey{quizID: quizID, userID: userID}].Score, nil
+}
+
+// quizAnswers returns a quiz's answers, optionally for one user, ordered by
+// when they were given. Callers hold mu.
+func (r *memoryQuizRepository) quizAnswers(quizID uuid.UUID, userID *uuid.UUID) []model.UserAnswer {
+	var answers []model.UserAnswer
+	for _, a := range r.answers {
+		if r.questions[a.QuestionID].QuizSessionID != quizID {
+			continue
+		}
+		if userID != nil && a.UserID != *userID {
+			continue
+		}
+		answers = append(answers, a)
+	}
+	sort.SliceStable(answers, func(i, j int) bool {
+		return answers[i].AnsweredAt.Before(answers[j].AnsweredAt)
+	})
+	return answers
+}
+
+func (r *memoryQuizRepository) GetUserAnswers(ctx context.Context, userID, quizID uuid.UUID) ([]model.UserAnswer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.quizAnswers(quizID, &userID), nil
+}
+
+func (r *memoryQuizRepository) GetAnswerCounts(ctx context.Context, quizID uuid.UUID) ([]model.AnswerCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type option struct {
+		questionID uuid.UUID
+		answer     string
+	}
+	index := make(map[option]int)
+	var counts []model.AnswerCount
+	for _, a := range r.quizAnswers(quizID, nil) {
+		key := option{questionID: a.QuestionID, answer: a.Answer}
+		i, ok := index[key]
+		if !ok {
+			i = len(counts)
+			index[key] = i
+			counts = append(counts, model.AnswerCount{QuestionID: a.QuestionID, Answer: a.Answer})
+		}
+		counts[i].Count++
+	}
+	return counts, nil
+}
+
+func (r *memoryQuizRepository) GetQuizAnswers(ctx context.Context, quizID uuid.UUID) ([]model.UserAnswer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.quizAnswers(quizID, nil), nil
+}
+
+func (r *memoryQuizRepository) GetParticipants(ctx context.Context, quizID uuid.UUID) ([]model.Participant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var participants []model.Participant
+	for key, score := range r.scores {
+		if key.quizID != quizID {
+			continue
+		}
+		user := r.users[key.userID]
+		p := model.Participant{
+			UserID:   user.ID,
+			QuizID:   quizID,
+			Username: user.Username,
+			Score:    score.Score,
+			JoinedAt: user.CreatedAt,
+		}
+		if member, ok := r.members[key]; ok {
+			teamID := member.TeamID
+			p.TeamID = &teamID
+			p.TeamName = r.teams[teamID].Name
+		}
+		participants = append(participants, p)
+	}
+
+	sort.Slice(participants, func(i, j int) bool {
+		if participants[i].Score != participants[j].Score {
+			return participants[i].Score > participants[j].Score
+		}
+		return participants[i].Username < participants[j].Username
+	})
+	return participants, nil
+}
+
+func (r *memoryQuizRepository) AddParticipant(ctx context.Context, participant *model.Participant) error {
+	// This is handled implicitly when user submits first answer
+	return nil
+}
+
+func (r *memoryQuizRepository) SaveQuizResults(ctx context.Context, quizID uuid.UUID, results []model.QuizResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Re-completing a quiz replaces its previous standings
+	stored := make([]model.QuizResult, len(results))
+	for i := range results {
+		newID(&results[i].ID)
+		stamp(&results[i].CreatedAt)
+		stored[i] = results[i]
+	}
+	r.results[quizID] = stored
+	return nil
+}
+
+func (r *memoryQuizRepository) GetQuizResults(ctx context.Context, quizID uuid.UUID) ([]model.QuizResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := append([]model.QuizResult(nil), r.results[quizID]...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank < results[j].Rank
+	})
+	return results, nil
+}
+
+// userQuizResults joins frozen results matching keep with their quiz title
+// and participant count, most recent first. Callers hold mu.
+func (r *memoryQuizRepository) userQuizResults(keep func(model.QuizResult) bool) []model.UserQuizResult {
+	var results []model.UserQuizResult
+	for quizID, standings := range r.results {
+		for _, qr := range standings {
+			if !keep(qr) {
+				continue
+			}
+			results = append(results, model.UserQuizResult{
+				QuizID:       quizID,
+				Title:        r.quizzes[quizID].Title,
+				UserID:       qr.UserID,
+				Username:     qr.Username,
+				Rank:         qr.Rank,
+				Score:        qr.Score,
+				Participants: len(standings),
+				CompletedAt:  qr.CompletedAt,
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CompletedAt.After(results[j].CompletedAt)
+	})
+	return results
+}
+
+func (r *memoryQuizRepository) GetUserResults(ctx context.Context, userID uuid.UUID) ([]model.UserQuizResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.userQuizResults(func(qr model.QuizResult) bool {
+		return qr.UserID == userID
+	}), nil
+}
+
+func (r *memoryQuizRepository) GetRecentWinners(ctx context.Context, limit int) ([]model.UserQuizResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	winners := r.userQuizResults(func(qr model.QuizResult) bool {
+		return qr.Rank == 1
+	})
+	if len(winners) > limit {
+		winners = winners[:limit]
+	}
+	return winners, nil
+}
+
+func (r *memoryQuizRepository) CreateSeries(ctx context.Context, series *model.Series) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newID(&series.ID)
+	if _, exists := r.series[series.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	stamp(&series.CreatedAt)
+
+	stored := *series
+	stored.Quizzes = nil
+	r.series[series.ID] = stored
+	return nil
+}
+
+func (r *memoryQuizRepository) GetSeries(ctx context.Context, id uuid.UUID) (*model.Series, error) {
+	r.mu.RLock()
+	series, ok := r.series[id]
+	r.mu.RUnlock()
+	if !ok {
+		return &model.Series{}, gorm.ErrRecordNotFound
+	}
+
+	series.Quizzes = r.findQuizzes(func(q model.QuizSession) bool {
+		return q.SeriesID != nil && *q.SeriesID == id
+	})
+	return &series, nil
+}
+
+func (r *memoryQuizRepository) AddQuizToSeries(ctx context.Context, seriesID, quizID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	quiz, ok := r.quizzes[quizID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	quiz.SeriesID = &seriesID
+	r.quizzes[quizID] = quiz
+	return nil
+}
+
+func (r *memoryQuizRepository) GetSeriesScores(ctx context.Context, seriesID uuid.UUID) ([]model.SeriesScore, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index := make(map[memberKey]int)
+	var scores []model.SeriesScore
+	for _, a := range r.answers {
+		question := r.questions[a.QuestionID]
+		quiz := r.quizzes[question.QuizSessionID]
+		if quiz.SeriesID == nil || *quiz.SeriesID != seriesID {
+			continue
+		}
+
+		key := memberKey{quizID: quiz.ID, userID: a.UserID}
+		i, ok := index[key]
+		if !ok {
+			i = len(scores)
+			index[key] = i
+			scores = append(scores, model.SeriesScore{
+				QuizID:   quiz.ID,
+				UserID:   a.UserID,
+				Username: r.users[a.UserID].Username,
+			})
+		}
+		if a.IsCorrect {
+			scores[i].Score += question.Points
+		}
+	}
+	return scores, nil
+}
+
+func (r *memoryQuizRepository) CreateTeam(ctx context.Context, team *model.Team) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newID(&team.ID)
+	for _, t := range r.teams {
+		if t.ID == team.ID || (t.QuizSessionID == team.QuizSessionID && t.Name == team.Name) {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	stamp(&team.CreatedAt)
+
+	stored := *team
+	stored.Members = nil
+	r.teams[team.ID] = stored
+	return nil
+}
+
+func (r *memoryQuizRepository) GetTeams(ctx context.Context, quizID uuid.UUID) ([]model.Team, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var teams []model.Team
+	for _, t := range r.teams {
+		if t.QuizSessionID != quizID {
+			continue
+		}
+		for _, m := range r.members {
+			if m.TeamID == t.ID {
+				t.Members = append(t.Members, m)
+			}
+		}
+		sort.Slice(t.Members, func(i, j int) bool {
+			return t.Members[i].JoinedAt.Before(t.Members[j].JoinedAt)
+		})
+		teams = append(teams, t)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].Name < teams[j].Name
+	})
+	return teams, nil
+}
+
+func (r *memoryQuizRepository) GetTeamByName(ctx context.Context, quizID uuid.UUID, name string) (*model.Team, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.teams {
+		if t.QuizSessionID == quizID && t.Name == name {
+			return &t, nil
+		}
+	}
+	return &model.Team{}, gorm.ErrRecordNotFound
+}
+
+func (r *memoryQuizRepository) SetTeamMember(ctx context.Context, member *model.TeamMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A user switching teams replaces their previous membership
+	r.members[memberKey{quizID: member.QuizSessionID, userID: member.UserID}] = *member
+	return nil
+}
+
+func (r *memoryQuizRepository) CreateAttempt(ctx context.Context, attempt *model.QuizAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newID(&attempt.ID)
+	for _, a := range r.attempts {
+		if a.ID == attempt.ID ||
+			(a.QuizSessionID == attempt.QuizSessionID && a.UserID == attempt.UserID && a.Number == attempt.Number) {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	r.attempts[attempt.ID] = *attempt
+	return nil
+}
+
+// userAttempts returns one participant's attempts by number. Callers hold mu.
+func (r *memoryQuizRepository) userAttempts(quizID, userID uuid.UUID) []model.QuizAttempt {
+	var attempts []model.QuizAttempt
+	for _, a := range r.attempts {
+		if a.QuizSessionID == quizID && a.UserID == userID {
+			attempts = append(attempts, a)
+		}
+	}
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].Number < attempts[j].Number
+	})
+	return attempts
+}
+
+func (r *memoryQuizRepository) GetAttempt(ctx context.Context, quizID, userID uuid.UUID) (*model.QuizAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attempts := r.userAttempts(quizID, userID)
+	if len(attempts) == 0 {
+		return &model.QuizAttempt{}, gorm.ErrRecordNotFound
+	}
+	return &attempts[len(attempts)-1], nil
+}
+
+func (r *memoryQuizRepository) ListAttempts(ctx context.Context, quizID, userID uuid.UUID) ([]model.QuizAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.userAttempts(quizID, userID), nil
+}
+
+// attemptScores sums the points earned within each attempt of a quiz,
+// optionally for one user, ordered by attempt number. Callers hold mu.
+func (r *memoryQuizRepository) attemptScores(quizID uuid.UUID, userID *uuid.UUID) []model.AttemptScore {
+	var scores []model.AttemptScore
+	for _, a := range r.attempts {
+		if a.QuizSessionID != quizID || (userID != nil && a.UserID != *userID) {
+			continue
+		}
+
+		score := model.AttemptScore{AttemptID: a.ID, UserID: a.UserID, Number: a.Number, Status: a.Status}
+		for _, ua := range r.answers {
+			if ua.AttemptID != nil && *ua.AttemptID == a.ID && ua.IsCorrect {
+				score.Score += r.questions[ua.QuestionID].Points
+			}
+		}
+		scores = append(scores, score)
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Number < scores[j].Number
+	})
+	return scores
+}
+
+func (r *memoryQuizRepository) GetAttemptScores(ctx context.Context, quizID uuid.UUID) ([]model.AttemptScore, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.attemptScores(quizID, nil), nil
+}
+
+func (r *memoryQuizRepository) GetUserAttemptScores(ctx context.Context, quizID, userID uuid.UUID) ([]model.AttemptScore, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.attemptScores(quizID, &userID), nil
+}
+
+func (r *memoryQuizRepository) UpdateAttempt(ctx context.Context, attempt *model.QuizAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newID(&attempt.ID)
+	r.attempts[attempt.ID] = *attempt
+	return nil
+}
+
+func (r *memoryQuizRepository) FinishExpiredAttempts(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var finished int64
+	for id, a := range r.attempts {
+		if a.Status == "in_progress" && a.Deadline.Before(now) {
+			deadline := a.Deadline
+			a.Status = "timed_out"
+			a.EndedAt = &deadline
+			r.attempts[id] = a
+			finished++
+		}
+	}
+	return finished, nil
+}
@@ -0,0 +1,384 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"quiz-app/internal/config"
+	"quiz-app/internal/model"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// memoryEntry is one key of the in-memory store. Exactly one of value, zset
+// or hash is set, mirroring Redis' string, sorted set and hash types.
+type memoryEntry struct {
+	value     []byte
+	zset      map[string]float64
+	hash      map[string]string
+	expiresAt time.Time // zero means no expiry
+}
+
+// memoryRedisRepository implements RedisRepository in process memory, with
+// the same key layout, expiry and sorted-set ordering as Redis. Missing keys
+// yield redis.Nil like the real client.
+type memoryRedisRepository struct {
+	mu   sync.Mutex
+	keys map[string]*memoryEntry
+	ttls cacheTTLs
+}
+
+func NewMemoryRedisRepository(cfg config.Redis) RedisRepository {
+	return &memoryRedisRepository{keys: make(map[string]*memoryEntry), ttls: newCacheTTLs(cfg)}
+}
+
+// entry returns a live key, dropping it first if it has expired. Callers hold mu.
+func (r *memoryRedisRepository) entry(key string) *memoryEntry {
+	e, ok := r.keys[key]
+	if !ok {
+		return nil
+	}
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		delete(r.keys, key)
+		return nil
+	}
+	return e
+}
+
+func (r *memoryRedisRepository) expire(key string, ttl time.Duration) {
+	if e := r.entry(key); e != nil {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+}
+
+func (r *memoryRedisRepository) set(key string, value []byte, ttl time.Duration) {
+	e := &memoryEntry{value: value}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+	r.keys[key] = e
+}
+
+func (r *memoryRedisRepository) get(key string) ([]byte, error) {
+	e := r.entry(key)
+	if e == nil || e.value == nil {
+		return nil, redis.Nil
+	}
+	return e.value, nil
+}
+
+func (r *memoryRedisRepository) zsetFor(key string) map[string]float64 {
+	e := r.entry(key)
+	if e == nil {
+		e = &memoryEntry{zset: make(map[string]float64)}
+		r.keys[key] = e
+	}
+	return e.zset
+}
+
+func (r *memoryRedisRepository) hashFor(key string) map[string]string {
+	e := r.entry(key)
+	if e == nil {
+		e = &memoryEntry{hash: make(map[string]string)}
+		r.keys[key] = e
+	}
+	return e.hash
+}
+
+// zrevrange returns a sorted set highest score first; equal scores are in
+// reverse lexicographical order of member, as with ZREVRANGE.
+func (r *memoryRedisRepository) zrevrange(key string) []redis.Z {
+	e := r.entry(key)
+	if e == nil {
+		return nil
+	}
+
+	results := make([]redis.Z, 0, len(e.zset))
+	for member, score := range e.zset {
+		results = append(results, redis.Z{Score: score, Member: member})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Member.(string) > results[j].Member.(string)
+	})
+	return results
+}
+
+func (r *memoryRedisRepository) replaceSortedSet(key string, members []*redis.Z) {
+	delete(r.keys, key)
+	if len(members) == 0 {
+		return
+	}
+
+	zset := r.zsetFor(key)
+	for _, m := range members {
+		zset[m.Member.(string)] = m.Score
+	}
+	r.expire(key, r.ttls.leaderboard)
+}
+
+func (r *memoryRedisRepository) SetQuizSession(ctx context.Context, quizID string, session *model.QuizSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set(fmt.Sprintf("quiz:%s", quizID), data, r.ttls.quiz)
+	return nil
+}
+
+func (r *memoryRedisRepository) GetQuizSession(ctx context.Context, quizID string) (*model.QuizSession, error) {
+	r.mu.Lock()
+	data, err := r.get(fmt.Sprintf("quiz:%s", quizID))
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var session model.QuizSession
+	err = json.Unmarshal(data, &session)
+	return &session, err
+}
+
+func (r *memoryRedisRepository) UpdateLeaderboard(ctx context.Context, quizID string, participants []model.Participant) error {
+	members := make([]*redis.Z, 0, len(participants))
+	for _, p := range participants {
+		members = append(members, leaderboardMember(p.UserID, p.Username, p.Score))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replaceSortedSet(fmt.Sprintf("leaderboard:%s", quizID), members)
+	return nil
+}
+
+func (r *memoryRedisRepository) GetLeaderboard(ctx context.Context, quizID string) ([]model.LeaderboardEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return parseLeaderboard(r.zrevrange(fmt.Sprintf("leaderboard:%s", quizID))), nil
+}
+
+func (r *memoryRedisRepository) UpdateTeamLeaderboard(ctx context.Context, quizID string, leaderboard []model.TeamLeaderboardEntry) error {
+	members := make([]*redis.Z, 0, len(leaderboard))
+	for _, e := range leaderboard {
+		members = append(members, teamLeaderboardMember(e))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replaceSortedSet(fmt.Sprintf("team_leaderboard:%s", quizID), members)
+	return nil
+}
+
+func (r *memoryRedisRepository) GetTeamLeaderboard(ctx context.Context, quizID string) ([]model.TeamLeaderboardEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return parseTeamLeaderboard(r.zrevrange(fmt.Sprintf("team_leaderboard:%s", quizID))), nil
+}
+
+func (r *memoryRedisRepository) UpdateSeriesLeaderboard(ctx context.Context, seriesID string, leaderboard []model.LeaderboardEntry) error {
+	members := make([]*redis.Z, 0, len(leaderboard))
+	for _, e := range leaderboard {
+		members = append(members, leaderboardMember(e.UserID, e.Username, e.Score))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replaceSortedSet(fmt.Sprintf("series_leaderboard:%s", seriesID), members)
+	return nil
+}
+
+func (r *memoryRedisRepository) GetSeriesLeaderboard(ctx context.Context, seriesID string) ([]model.LeaderboardEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return parseLeaderboard(r.zrevrange(fmt.Sprintf("series_leaderboard:%s", seriesID))), nil
+}
+
+func (r *memoryRedisRepository) DeleteKey(ctx context.Context, key string) error {
+	r.mu.Lock()
+	delete(r.keys, key)
+	r.mu.Unlock()
+
+	slog.DebugContext(ctx, "Deleted cache key", "key", key)
+	return nil
+}
+
+func (r *memoryRedisRepository) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set(key, data, ttl)
+	return nil
+}
+
+// GetTTL follows the Redis convention of -2 for a missing key and -1 for a
+// key without expiry.
+func (r *memoryRedisRepository) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := r.entry(key)
+	switch {
+	case e == nil:
+		return -2, nil
+	case e.expiresAt.IsZero():
+		return -1, nil
+	default:
+		return time.Until(e.expiresAt), nil
+	}
+}
+
+func (r *memoryRedisRepository) KeyExists(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.entry(key) != nil, nil
+}
+
+func (r *memoryRedisRepository) FlushCache(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = make(map[string]*memoryEntry)
+	return nil
+}
+
+func (r *memoryRedisRepository) AddLobbyParticipant(ctx context.Context, quizID, userID, username string) error {
+	key := fmt.Sprintf("lobby:%s", quizID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hashFor(key)[userID] = username
+	r.expire(key, lobbyTTL)
+	return nil
+}
+
+func (r *memoryRedisRepository) RemoveLobbyParticipant(ctx context.Context, quizID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fmt.Sprintf("lobby:%s", quizID)
+	if e := r.entry(key); e != nil {
+		delete(e.hash, userID)
+		if len(e.hash) == 0 {
+			delete(r.keys, key)
+		}
+	}
+	return nil
+}
+
+func (r *memoryRedisRepository) GetLobbyParticipants(ctx context.Context, quizID string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	participants := make(map[string]string)
+	if e := r.entry(fmt.Sprintf("lobby:%s", quizID)); e != nil {
+		for userID, username := range e.hash {
+			participants[userID] = username
+		}
+	}
+	return participants, nil
+}
+
+func (r *memoryRedisRepository) TouchPresence(ctx context.Context, quizID, userID string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("presence:%s", quizID)
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	zset := r.zsetFor(key)
+	wasOnline := false
+	if previous, ok := zset[userID]; ok {
+		wasOnline = now.Sub(time.UnixMilli(int64(previous))) < ttl
+	}
+	zset[userID] = float64(now.UnixMilli())
+	r.expire(key, lobbyTTL)
+	return wasOnline, nil
+}
+
+func (r *memoryRedisRepository) ClearPresence(ctx context.Context, quizID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fmt.Sprintf("presence:%s", quizID)
+	if e := r.entry(key); e != nil {
+		delete(e.zset, userID)
+		if len(e.zset) == 0 {
+			delete(r.keys, key)
+		}
+	}
+	return nil
+}
+
+func (r *memoryRedisRepository) GetPresence(ctx context.Context, quizID string) (map[string]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	presence := make(map[string]time.Time)
+	if e := r.entry(fmt.Sprintf("presence:%s", quizID)); e != nil {
+		for userID, score := range e.zset {
+			presence[userID] = time.UnixMilli(int64(score))
+		}
+	}
+	return presence, nil
+}
+
+func (r *memoryRedisRepository) SaveParticipantToken(ctx context.Context, token string, quizID, userID string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set(fmt.Sprintf("participant_token:%s", token), []byte(quizID+":"+userID), ttl)
+	return nil
+}
+
+func (r *memoryRedisRepository) GetParticipantToken(ctx context.Context, token string) (string, string, error) {
+	r.mu.Lock()
+	value, err := r.get(fmt.Sprintf("participant_token:%s", token))
+	r.mu.Unlock()
+	if err != nil {
+		return "", "", err
+	}
+
+	quizID, userID, ok := strings.Cut(string(value), ":")
+	if !ok {
+		return "", "", fmt.Errorf("malformed participant token")
+	}
+	return quizID, userID, nil
+}
+
+func (r *memoryRedisRepository) AcquireLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return "", false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.entry(key) != nil {
+		return "", false, nil
+	}
+	r.set(key, []byte(token), ttl)
+	return token, true, nil
+}
+
+func (r *memoryRedisRepository) ReleaseLock(ctx context.Context, key, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e := r.entry(key); e != nil && string(e.value) == token {
+		delete(r.keys, key)
+	}
+	return nil
+}
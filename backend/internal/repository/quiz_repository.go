@@ -1,104 +1,601 @@
 package repository
 
 import (
+	"context"
 	"quiz-app/internal/model"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type QuizRepository interface {
-	CreateQuiz(quiz *model.QuizSession) error
-	GetQuiz(id uuid.UUID) (*model.QuizSession, error)
-	CreateUser(user *model.User) error
-	GetUser(id uuid.UUID) (*model.User, error)
-	GetUserByUsername(username string) (*model.User, error)
-	SaveAnswer(answer *model.UserAnswer) error
-	GetUserScore(userID, quizID uuid.UUID) (int, error)
-	GetParticipants(quizID uuid.UUID) ([]model.Participant, error)
-	AddParticipant(participant *model.Participant) error
+	CreateQuiz(ctx context.Context, quiz *model.QuizSession) error
+	GetQuiz(ctx context.Context, id uuid.UUID) (*model.QuizSession, error)
+	UpdateQuizStatus(ctx context.Context, id uuid.UUID, status string) error
+	GetExpiredQuizzes(ctx context.Context, now time.Time) ([]model.QuizSession, error)
+	GetScheduledQuizzes(ctx context.Context, before time.Time) ([]model.QuizSession, error)
+	StartScheduledQuiz(ctx context.Context, id uuid.UUID) (bool, error)
+	GetQuizIDsCompletedBefore(ctx context.Context, statuses []string, cutoff time.Time) ([]uuid.UUID, error)
+	GetQuizIDsByStatus(ctx context.Context, status string) ([]uuid.UUID, error)
+	ArchiveQuizzes(ctx context.Context, ids []uuid.UUID) error
+	DeleteQuizzes(ctx context.Context, ids []uuid.UUID) error
+	CreateUser(ctx context.Context, user *model.User) error
+	GetUser(ctx context.Context, id uuid.UUID) (*model.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
+	RecordAnswer(ctx context.Context, quizID uuid.UUID, answer *model.UserAnswer, points int) (*model.ParticipantScore, error)
+	GetUserScore(ctx context.Context, userID, quizID uuid.UUID) (int, error)
+	GetUserAnswers(ctx context.Context, userID, quizID uuid.UUID) ([]model.UserAnswer, error)
+	GetAnswerCounts(ctx context.Context, quizID uuid.UUID) ([]model.AnswerCount, error)
+	GetQuizAnswers(ctx context.Context, quizID uuid.UUID) ([]model.UserAnswer, error)
+	GetParticipants(ctx context.Context, quizID uuid.UUID) ([]model.Participant, error)
+	AddParticipant(ctx context.Context, participant *model.Participant) error
+	SaveQuizResults(ctx context.Context, quizID uuid.UUID, results []model.QuizResult) error
+	GetQuizResults(ctx context.Context, quizID uuid.UUID) ([]model.QuizResult, error)
+	GetUserResults(ctx context.Context, userID uuid.UUID) ([]model.UserQuizResult, error)
+	GetRecentWinners(ctx context.Context, limit int) ([]model.UserQuizResult, error)
+	CreateSeries(ctx context.Context, series *model.Series) error
+	GetSeries(ctx context.Context, id uuid.UUID) (*model.Series, error)
+	AddQuizToSeries(ctx context.Context, seriesID, quizID uuid.UUID) error
+	GetSeriesScores(ctx context.Context, seriesID uuid.UUID) ([]model.SeriesScore, error)
+	CreateTeam(ctx context.Context, team *model.Team) error
+	GetTeams(ctx context.Context, quizID uuid.UUID) ([]model.Team, error)
+	GetTeamByName(ctx context.Context, quizID uuid.UUID, name string) (*model.Team, error)
+	SetTeamMember(ctx context.Context, member *model.TeamMember) error
+	CreateAttempt(ctx context.Context, attempt *model.QuizAttempt) error
+	GetAttempt(ctx context.Context, quizID, userID uuid.UUID) (*model.QuizAttempt, error)
+	ListAttempts(ctx context.Context, quizID, userID uuid.UUID) ([]model.QuizAttempt, error)
+	GetAttemptScores(ctx context.Context, quizID uuid.UUID) ([]model.AttemptScore, error)
+	GetUserAttemptScores(ctx context.Context, quizID, userID uuid.UUID) ([]model.AttemptScore, error)
+	UpdateAttempt(ctx context.Context, attempt *model.QuizAttempt) error
+	FinishExpiredAttempts(ctx context.Context, now time.Time) (int64, error)
 }
 
 type quizRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	timeout time.Duration
 }
 
-func NewQuizRepository(db *gorm.DB) QuizRepository {
-	return &quizRepository{db: db}
+// NewQuizRepository bounds every query by timeout; zero means no limit
+// beyond the caller's context.
+func NewQuizRepository(db *gorm.DB, timeout time.Duration) QuizRepository {
+	return &quizRepository{db: db, timeout: timeout}
 }
 
-func (r *quizRepository) CreateQuiz(quiz *model.QuizSession) error {
-	return r.db.Create(quiz).Error
+func (r *quizRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
 }
 
-func (r *quizRepository) GetQuiz(id uuid.UUID) (*model.QuizSession, error) {
+func (r *quizRepository) CreateQuiz(ctx context.Context, quiz *model.QuizSession) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(quiz).Error
+}
+
+func (r *quizRepository) GetQuiz(ctx context.Context, id uuid.UUID) (*model.QuizSession, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var quiz model.QuizSession
-	err := r.db.Preload("Questions").Where("id = ?", id).First(&quiz).Error
+	err := r.db.WithContext(ctx).Preload("Questions", func(db *gorm.DB) *gorm.DB {
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Name: "order"}})
+	}).Preload("Teams", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	}).Where("id = ?", id).First(&quiz).Error
 	return &quiz, err
 }
 
-func (r *quizRepository) CreateUser(user *model.User) error {
-	return r.db.Create(user).Error
+func (r *quizRepository) UpdateQuizStatus(ctx context.Context, id uuid.UUID, status string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	updates := map[string]interface{}{"status": status}
+	if status == "completed" {
+		updates["completed_at"] = time.Now()
+	}
+	result := r.db.WithContext(ctx).Model(&model.QuizSession{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetExpiredQuizzes returns quizzes past their expiry that are still open.
+func (r *quizRepository) GetExpiredQuizzes(ctx context.Context, now time.Time) ([]model.QuizSession, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var quizzes []model.QuizSession
+	err := r.db.WithContext(ctx).Where("status IN ? AND expires_at < ?", []string{"waiting", "active"}, now).
+		Find(&quizzes).Error
+	return quizzes, err
+}
+
+// GetScheduledQuizzes returns waiting quizzes scheduled to start before the
+// given time, including ones already overdue.
+func (r *quizRepository) GetScheduledQuizzes(ctx context.Context, before time.Time) ([]model.QuizSession, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var quizzes []model.QuizSession
+	err := r.db.WithContext(ctx).Where("status = ? AND starts_at IS NOT NULL AND starts_at <= ?", "waiting", before).
+		Order("starts_at").
+		Find(&quizzes).Error
+	return quizzes, err
+}
+
+// StartScheduledQuiz activates a quiz only if it is still waiting, reporting
+// whether this call made the change.
+func (r *quizRepository) StartScheduledQuiz(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Model(&model.QuizSession{}).
+		Where("id = ? AND status = ?", id, "waiting").
+		Update("status", "active")
+	return result.RowsAffected > 0, result.Error
 }
 
-func (r *quizRepository) GetUser(id uuid.UUID) (*model.User, error) {
+// GetQuizIDsCompletedBefore returns quizzes in one of statuses that completed
+// before cutoff.
+func (r *quizRepository) GetQuizIDsCompletedBefore(ctx context.Context, statuses []string, cutoff time.Time) ([]uuid.UUID, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&model.QuizSession{}).
+		Where("status IN ? AND completed_at < ?", statuses, cutoff).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *quizRepository) GetQuizIDsByStatus(ctx context.Context, status string) ([]uuid.UUID, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&model.QuizSession{}).
+		Where("status = ?", status).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *quizRepository) ArchiveQuizzes(ctx context.Context, ids []uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.QuizSession{}).Where("id IN ?", ids).Update("status", "archived").Error
+}
+
+// DeleteQuizzes removes quizzes together with their questions, answers,
+// attempts, teams and frozen results.
+func (r *quizRepository) DeleteQuizzes(ctx context.Context, ids []uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		questionIDs := tx.Model(&model.Question{}).Select("id").Where("quiz_session_id IN ?", ids)
+		if err := tx.Where("question_id IN (?)", questionIDs).Delete(&model.UserAnswer{}).Error; err != nil {
+			return err
+		}
+		for _, m := range []interface{}{
+			&model.Question{},
+			&model.QuizAttempt{},
+			&model.QuizResult{},
+			&model.ParticipantScore{},
+			&model.TeamMember{},
+			&model.Team{},
+		} {
+			if err := tx.Where("quiz_session_id IN ?", ids).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id IN ?", ids).Delete(&model.QuizSession{}).Error
+	})
+}
+
+func (r *quizRepository) CreateUser(ctx context.Context, user *model.User) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *quizRepository) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var user model.User
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
 	return &user, err
 }
 
-func (r *quizRepository) GetUserByUsername(username string) (*model.User, error) {
+func (r *quizRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var user model.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	return &user, err
 }
 
-func (r *quizRepository) SaveAnswer(answer *model.UserAnswer) error {
-	return r.db.Create(answer).Error
+// RecordAnswer saves an answer and adds its points to the participant's
+// running score in one transaction. The upsert locks the score row, so
+// concurrent answers from the same participant apply one at a time and each
+// reads back the total including every answer committed before it.
+func (r *quizRepository) RecordAnswer(ctx context.Context, quizID uuid.UUID, answer *model.UserAnswer, points int) (*model.ParticipantScore, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	score := &model.ParticipantScore{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(answer).Error; err != nil {
+			return err
+		}
+
+		row := &model.ParticipantScore{
+			QuizSessionID: quizID,
+			UserID:        answer.UserID,
+			Score:         points,
+			Answers:       1,
+			UpdatedAt:     answer.AnsweredAt,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "quiz_session_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"score":      gorm.Expr("participant_scores.score + ?", points),
+				"answers":    gorm.Expr("participant_scores.answers + 1"),
+				"updated_at": answer.AnsweredAt,
+			}),
+		}).Create(row).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("quiz_session_id = ? AND user_id = ?", quizID, answer.UserID).First(score).Error; err != nil {
+			return err
+		}
+		if answer.AttemptID == nil {
+			return nil
+		}
+		return attemptScores(tx, quizID).Where("a.user_id = ?", answer.UserID).Scan(&score.Attempts).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return score, nil
 }
 
-func (r *quizRepository) GetUserScore(userID, quizID uuid.UUID) (int, error) {
+func (r *quizRepository) GetUserScore(ctx context.Context, userID, quizID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var totalScore int
-	err := r.db.Model(&model.UserAnswer{}).
-		Select("COALESCE(SUM(CASE WHEN is_correct THEN q.points ELSE 0 END), 0)").
-		Joins("JOIN questions q ON user_answers.question_id = q.id").
-		Where("user_answers.user_id = ? AND q.quiz_session_id = ?", userID, quizID).
+	err := r.db.WithContext(ctx).Model(&model.ParticipantScore{}).
+		Select("COALESCE(SUM(score), 0)").
+		Where("quiz_session_id = ? AND user_id = ?", quizID, userID).
 		Scan(&totalScore).Error
 	return totalScore, err
 }
 
-func (r *quizRepository) GetParticipants(quizID uuid.UUID) ([]model.Participant, error) {
+func (r *quizRepository) GetUserAnswers(ctx context.Context, userID, quizID uuid.UUID) ([]model.UserAnswer, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var answers []model.UserAnswer
+	err := r.db.WithContext(ctx).Select("user_answers.*").
+		Joins("JOIN questions q ON user_answers.question_id = q.id").
+		Where("user_answers.user_id = ? AND q.quiz_session_id = ?", userID, quizID).
+		Order("user_answers.answered_at").
+		Find(&answers).Error
+	return answers, err
+}
+
+func (r *quizRepository) GetAnswerCounts(ctx context.Context, quizID uuid.UUID) ([]model.AnswerCount, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var counts []model.AnswerCount
+	err := r.db.WithContext(ctx).Model(&model.UserAnswer{}).
+		Select("user_answers.question_id, user_answers.answer, COUNT(*) as count").
+		Joins("JOIN questions q ON user_answers.question_id = q.id").
+		Where("q.quiz_session_id = ?", quizID).
+		Group("user_answers.question_id, user_answers.answer").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *quizRepository) GetQuizAnswers(ctx context.Context, quizID uuid.UUID) ([]model.UserAnswer, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var answers []model.UserAnswer
+	err := r.db.WithContext(ctx).Select("user_answers.*").
+		Joins("JOIN questions q ON user_answers.question_id = q.id").
+		Where("q.quiz_session_id = ?", quizID).
+		Order("user_answers.answered_at").
+		Find(&answers).Error
+	return answers, err
+}
+
+func (r *quizRepository) GetParticipants(ctx context.Context, quizID uuid.UUID) ([]model.Participant, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var participants []model.Participant
-	err := r.db.Raw(`
+	err := r.db.WithContext(ctx).Raw(`
         SELECT 
             u.id as user_id,
             u.username,
-            ? as quiz_id,
-            COALESCE(scores.score, 0) as score,
+            t.id as team_id,
+            COALESCE(t.name, '') as team_name,
+            ps.score,
             u.created_at as joined_at
-        FROM users u
-        LEFT JOIN (
-            SELECT 
-                ua.user_id,
-                SUM(CASE WHEN ua.is_correct THEN q.points ELSE 0 END) as score
-            FROM user_answers ua
-            JOIN questions q ON ua.question_id = q.id
-            WHERE q.quiz_session_id = ?
-            GROUP BY ua.user_id
-        ) scores ON u.id = scores.user_id
-        WHERE u.id IN (
-            SELECT DISTINCT ua.user_id 
-            FROM user_answers ua 
-            JOIN questions q ON ua.question_id = q.id 
-            WHERE q.quiz_session_id = ?
-        )
-        ORDER BY score DESC
-    `, quizID, quizID, quizID).Scan(&participants).Error
+        FROM participant_scores ps
+        JOIN users u ON u.id = ps.user_id
+        LEFT JOIN team_members tm ON tm.user_id = u.id AND tm.quiz_session_id = ps.quiz_session_id
+        LEFT JOIN teams t ON t.id = tm.team_id
+        WHERE ps.quiz_session_id = ?
+        ORDER BY ps.score DESC, u.username
+    `, quizID).Scan(&participants).Error
 
+	// Set here rather than selected as a bound parameter, whose type not every
+	// driver can infer.
+	for i := range participants {
+		participants[i].QuizID = quizID
+	}
 	return participants, err
 }
 
-func (r *quizRepository) AddParticipant(participant *model.Participant) error {
+func (r *quizRepository) AddParticipant(ctx context.Context, participant *model.Participant) error {
 	// This is handled implicitly when user submits first answer
 	return nil
 }
+
+func (r *quizRepository) SaveQuizResults(ctx context.Context, quizID uuid.UUID, results []model.QuizResult) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Re-completing a quiz replaces its previous standings
+		if err := tx.Where("quiz_session_id = ?", quizID).Delete(&model.QuizResult{}).Error; err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		return tx.Create(&results).Error
+	})
+}
+
+func (r *quizRepository) GetQuizResults(ctx context.Context, quizID uuid.UUID) ([]model.QuizResult, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var results []model.QuizResult
+	err := r.db.WithContext(ctx).Where("quiz_session_id = ?", quizID).Order("rank").Find(&results).Error
+	return results, err
+}
+
+// userQuizResultQuery selects frozen results joined with their quiz title and
+// the number of participants in that quiz.
+const userQuizResultQuery = `
+        SELECT
+            qr.quiz_session_id as quiz_id,
+            qs.title,
+            qr.user_id,
+            qr.username,
+            qr.rank,
+            qr.score,
+            (SELECT COUNT(*) FROM quiz_results c WHERE c.quiz_session_id = qr.quiz_session_id) as participants,
+            qr.completed_at
+        FROM quiz_results qr
+        JOIN quiz_sessions qs ON qs.id = qr.quiz_session_id`
+
+func (r *quizRepository) GetUserResults(ctx context.Context, userID uuid.UUID) ([]model.UserQuizResult, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var results []model.UserQuizResult
+	err := r.db.WithContext(ctx).Raw(userQuizResultQuery+`
+        WHERE qr.user_id = ?
+        ORDER BY qr.completed_at DESC
+    `, userID).Scan(&results).Error
+	return results, err
+}
+
+func (r *quizRepository) GetRecentWinners(ctx context.Context, limit int) ([]model.UserQuizResult, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var results []model.UserQuizResult
+	err := r.db.WithContext(ctx).Raw(userQuizResultQuery+`
+        WHERE qr.rank = 1
+        ORDER BY qr.completed_at DESC
+        LIMIT ?
+    `, limit).Scan(&results).Error
+	return results, err
+}
+
+func (r *quizRepository) CreateSeries(ctx context.Context, series *model.Series) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(series).Error
+}
+
+func (r *quizRepository) GetSeries(ctx context.Context, id uuid.UUID) (*model.Series, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var series model.Series
+	err := r.db.WithContext(ctx).Preload("Quizzes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).Where("id = ?", id).First(&series).Error
+	return &series, err
+}
+
+func (r *quizRepository) AddQuizToSeries(ctx context.Context, seriesID, quizID uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Model(&model.QuizSession{}).Where("id = ?", quizID).Update("series_id", seriesID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *quizRepository) GetSeriesScores(ctx context.Context, seriesID uuid.UUID) ([]model.SeriesScore, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var scores []model.SeriesScore
+	err := r.db.WithContext(ctx).Raw(`
+        SELECT
+            q.quiz_session_id as quiz_id,
+            ua.user_id,
+            u.username,
+            SUM(CASE WHEN ua.is_correct THEN q.points ELSE 0 END) as score
+        FROM user_answers ua
+        JOIN questions q ON ua.question_id = q.id
+        JOIN quiz_sessions qs ON q.quiz_session_id = qs.id
+        JOIN users u ON ua.user_id = u.id
+        WHERE qs.series_id = ?
+        GROUP BY q.quiz_session_id, ua.user_id, u.username
+    `, seriesID).Scan(&scores).Error
+	return scores, err
+}
+
+func (r *quizRepository) CreateTeam(ctx context.Context, team *model.Team) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(team).Error
+}
+
+func (r *quizRepository) GetTeams(ctx context.Context, quizID uuid.UUID) ([]model.Team, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var teams []model.Team
+	err := r.db.WithContext(ctx).Preload("Members").Where("quiz_session_id = ?", quizID).Order("name").Find(&teams).Error
+	return teams, err
+}
+
+func (r *quizRepository) GetTeamByName(ctx context.Context, quizID uuid.UUID, name string) (*model.Team, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var team model.Team
+	err := r.db.WithContext(ctx).Where("quiz_session_id = ? AND name = ?", quizID, name).First(&team).Error
+	return &team, err
+}
+
+func (r *quizRepository) SetTeamMember(ctx context.Context, member *model.TeamMember) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// A user switching teams replaces their previous membership
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "quiz_session_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"team_id", "joined_at"}),
+	}).Create(member).Error
+}
+
+func (r *quizRepository) CreateAttempt(ctx context.Context, attempt *model.QuizAttempt) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
+
+func (r *quizRepository) GetAttempt(ctx context.Context, quizID, userID uuid.UUID) (*model.QuizAttempt, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var attempt model.QuizAttempt
+	err := r.db.WithContext(ctx).Where("quiz_session_id = ? AND user_id = ?", quizID, userID).
+		Order("number DESC").
+		First(&attempt).Error
+	return &attempt, err
+}
+
+func (r *quizRepository) ListAttempts(ctx context.Context, quizID, userID uuid.UUID) ([]model.QuizAttempt, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var attempts []model.QuizAttempt
+	err := r.db.WithContext(ctx).Where("quiz_session_id = ? AND user_id = ?", quizID, userID).
+		Order("number").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *quizRepository) GetAttemptScores(ctx context.Context, quizID uuid.UUID) ([]model.AttemptScore, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var scores []model.AttemptScore
+	err := attemptScores(r.db.WithContext(ctx), quizID).Scan(&scores).Error
+	return scores, err
+}
+
+func (r *quizRepository) GetUserAttemptScores(ctx context.Context, quizID, userID uuid.UUID) ([]model.AttemptScore, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var scores []model.AttemptScore
+	err := attemptScores(r.db.WithContext(ctx), quizID).Where("a.user_id = ?", userID).Scan(&scores).Error
+	return scores, err
+}
+
+// attemptScores sums the points earned within each attempt of a quiz.
+func attemptScores(db *gorm.DB, quizID uuid.UUID) *gorm.DB {
+	return db.Table("quiz_attempts a").
+		Select("a.id as attempt_id, a.user_id, a.number, a.status, "+
+			"COALESCE(SUM(CASE WHEN ua.is_correct THEN q.points ELSE 0 END), 0) as score").
+		Joins("LEFT JOIN user_answers ua ON ua.attempt_id = a.id").
+		Joins("LEFT JOIN questions q ON ua.question_id = q.id").
+		Where("a.quiz_session_id = ?", quizID).
+		Group("a.id, a.user_id, a.number, a.status").
+		Order("a.number")
+}
+
+func (r *quizRepository) UpdateAttempt(ctx context.Context, attempt *model.QuizAttempt) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Save(attempt).Error
+}
+
+// FinishExpiredAttempts times out every in-progress attempt whose deadline has
+// passed, ending it at its deadline.
+func (r *quizRepository) FinishExpiredAttempts(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Model(&model.QuizAttempt{}).
+		Where("status = ? AND deadline < ?", "in_progress", now).
+		Updates(map[string]interface{}{
+			"status":   "timed_out",
+			"ended_at": gorm.Expr("deadline"),
+		})
+	return result.RowsAffected, result.Error
+}
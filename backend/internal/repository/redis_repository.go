@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"quiz-app/internal/config"
 	"quiz-app/internal/model"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,42 +18,93 @@ import (
 )
 
 type RedisRepository interface {
-	SetQuizSession(quizID string, session *model.QuizSession) error
-	GetQuizSession(quizID string) (*model.QuizSession, error)
-	UpdateLeaderboard(quizID string, participants []model.Participant) error
-	GetLeaderboard(quizID string) ([]model.LeaderboardEntry, error)
+	SetQuizSession(ctx context.Context, quizID string, session *model.QuizSession) error
+	GetQuizSession(ctx context.Context, quizID string) (*model.QuizSession, error)
+	UpdateLeaderboard(ctx context.Context, quizID string, participants []model.Participant) error
+	GetLeaderboard(ctx context.Context, quizID string) ([]model.LeaderboardEntry, error)
+	UpdateTeamLeaderboard(ctx context.Context, quizID string, leaderboard []model.TeamLeaderboardEntry) error
+	GetTeamLeaderboard(ctx context.Context, quizID string) ([]model.TeamLeaderboardEntry, error)
+	UpdateSeriesLeaderboard(ctx context.Context, seriesID string, leaderboard []model.LeaderboardEntry) error
+	GetSeriesLeaderboard(ctx context.Context, seriesID string) ([]model.LeaderboardEntry, error)
 	// PublishLeaderboardUpdate(quizID string, leaderboard []model.LeaderboardEntry) error
 	// SubscribeToLeaderboardUpdates(quizID string) *redis.PubSub
 	// New cache management methods
-	DeleteKey(key string) error
-	SetWithTTL(key string, value interface{}, ttl time.Duration) error
-	GetTTL(key string) (time.Duration, error)
-	KeyExists(key string) (bool, error)
-	FlushCache() error
+	DeleteKey(ctx context.Context, key string) error
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	GetTTL(ctx context.Context, key string) (time.Duration, error)
+	KeyExists(ctx context.Context, key string) (bool, error)
+	FlushCache(ctx context.Context) error
+	AddLobbyParticipant(ctx context.Context, quizID, userID, username string) error
+	RemoveLobbyParticipant(ctx context.Context, quizID, userID string) error
+	GetLobbyParticipants(ctx context.Context, quizID string) (map[string]string, error)
+	TouchPresence(ctx context.Context, quizID, userID string, ttl time.Duration) (bool, error)
+	ClearPresence(ctx context.Context, quizID, userID string) error
+	GetPresence(ctx context.Context, quizID string) (map[string]time.Time, error)
+	SaveParticipantToken(ctx context.Context, token string, quizID, userID string, ttl time.Duration) error
+	GetParticipantToken(ctx context.Context, token string) (quizID, userID string, err error)
+	AcquireLock(ctx context.Context, key string, ttl time.Duration) (token string, acquired bool, err error)
+	ReleaseLock(ctx context.Context, key, token string) error
 }
 
 type redisRepository struct {
-	client *redis.Client
-	ctx    context.Context
+	client  *redis.Client
+	timeout time.Duration
+	ttls    cacheTTLs
 }
 
-func NewRedisRepository(client *redis.Client) RedisRepository {
+// NewRedisRepository bounds every operation by timeout; zero means no limit
+// beyond the caller's context. Cached quizzes and leaderboards expire per cfg.
+func NewRedisRepository(client *redis.Client, timeout time.Duration, cfg config.Redis) RedisRepository {
 	return &redisRepository{
-		client: client,
-		ctx:    context.Background(),
+		client:  client,
+		timeout: timeout,
+		ttls:    newCacheTTLs(cfg),
 	}
 }
 
-func (r *redisRepository) SetQuizSession(quizID string, session *model.QuizSession) error {
+// cacheTTLs is how long cached quizzes and leaderboards live in Redis: the
+// time they stay fresh plus the stale-while-revalidate window.
+type cacheTTLs struct {
+	quiz        time.Duration
+	leaderboard time.Duration
+}
+
+func newCacheTTLs(cfg config.Redis) cacheTTLs {
+	expiry := func(fresh time.Duration) time.Duration {
+		if fresh <= 0 {
+			fresh = time.Hour
+		}
+		if cfg.StaleWhileRevalidate > 0 {
+			fresh += cfg.StaleWhileRevalidate
+		}
+		return fresh
+	}
+	return cacheTTLs{quiz: expiry(cfg.QuizTTL), leaderboard: expiry(cfg.LeaderboardTTL)}
+}
+
+func (r *redisRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *redisRepository) SetQuizSession(ctx context.Context, quizID string, session *model.QuizSession) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	data, err := json.Marshal(session)
 	if err != nil {
 		return err
 	}
-	return r.client.Set(r.ctx, fmt.Sprintf("quiz:%s", quizID), data, time.Hour).Err()
+	return r.client.Set(ctx, fmt.Sprintf("quiz:%s", quizID), data, r.ttls.quiz).Err()
 }
 
-func (r *redisRepository) GetQuizSession(quizID string) (*model.QuizSession, error) {
-	data, err := r.client.Get(r.ctx, fmt.Sprintf("quiz:%s", quizID)).Result()
+func (r *redisRepository) GetQuizSession(ctx context.Context, quizID string) (*model.QuizSession, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	data, err := r.client.Get(ctx, fmt.Sprintf("quiz:%s", quizID)).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -59,32 +114,110 @@ func (r *redisRepository) GetQuizSession(quizID string) (*model.QuizSession, err
 	return &session, err
 }
 
-func (r *redisRepository) UpdateLeaderboard(quizID string, participants []model.Participant) error {
-	key := fmt.Sprintf("leaderboard:%s", quizID)
+func (r *redisRepository) UpdateLeaderboard(ctx context.Context, quizID string, participants []model.Participant) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	members := make([]*redis.Z, 0, len(participants))
+	for _, p := range participants {
+		members = append(members, leaderboardMember(p.UserID, p.Username, p.Score))
+	}
+	return r.replaceSortedSet(ctx, fmt.Sprintf("leaderboard:%s", quizID), members)
+}
+
+func (r *redisRepository) GetLeaderboard(ctx context.Context, quizID string) ([]model.LeaderboardEntry, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.readLeaderboard(ctx, fmt.Sprintf("leaderboard:%s", quizID))
+}
+
+func (r *redisRepository) UpdateTeamLeaderboard(ctx context.Context, quizID string, leaderboard []model.TeamLeaderboardEntry) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	members := make([]*redis.Z, 0, len(leaderboard))
+	for _, e := range leaderboard {
+		members = append(members, teamLeaderboardMember(e))
+	}
+	return r.replaceSortedSet(ctx, fmt.Sprintf("team_leaderboard:%s", quizID), members)
+}
+
+func (r *redisRepository) GetTeamLeaderboard(ctx context.Context, quizID string) ([]model.TeamLeaderboardEntry, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	key := fmt.Sprintf("team_leaderboard:%s", quizID)
+	results, err := r.client.ZRevRangeWithScores(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseTeamLeaderboard(results), nil
+}
+
+func (r *redisRepository) UpdateSeriesLeaderboard(ctx context.Context, seriesID string, leaderboard []model.LeaderboardEntry) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	members := make([]*redis.Z, 0, len(leaderboard))
+	for _, e := range leaderboard {
+		members = append(members, leaderboardMember(e.UserID, e.Username, e.Score))
+	}
+	return r.replaceSortedSet(ctx, fmt.Sprintf("series_leaderboard:%s", seriesID), members)
+}
+
+func (r *redisRepository) GetSeriesLeaderboard(ctx context.Context, seriesID string) ([]model.LeaderboardEntry, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.readLeaderboard(ctx, fmt.Sprintf("series_leaderboard:%s", seriesID))
+}
 
+// replaceSortedSet overwrites a leaderboard sorted set with the given members.
+func (r *redisRepository) replaceSortedSet(ctx context.Context, key string, members []*redis.Z) error {
 	// Clear existing leaderboard
-	r.client.Del(r.ctx, key)
+	r.client.Del(ctx, key)
 
 	// Add participants to sorted set
-	for _, p := range participants {
-		r.client.ZAdd(r.ctx, key, &redis.Z{
-			Score:  float64(p.Score),
-			Member: fmt.Sprintf("%s:%s", p.UserID, p.Username),
-		})
+	for _, m := range members {
+		r.client.ZAdd(ctx, key, m)
 	}
 
 	// Set expiration
-	r.client.Expire(r.ctx, key, time.Hour)
+	r.client.Expire(ctx, key, r.ttls.leaderboard)
 	return nil
 }
 
-func (r *redisRepository) GetLeaderboard(quizID string) ([]model.LeaderboardEntry, error) {
-	key := fmt.Sprintf("leaderboard:%s", quizID)
-	results, err := r.client.ZRevRangeWithScores(r.ctx, key, 0, -1).Result()
+// readLeaderboard returns the members of a leaderboard sorted set, highest
+// score first.
+func (r *redisRepository) readLeaderboard(ctx context.Context, key string) ([]model.LeaderboardEntry, error) {
+	results, err := r.client.ZRevRangeWithScores(ctx, key, 0, -1).Result()
 	if err != nil {
 		return nil, err
 	}
+	return parseLeaderboard(results), nil
+}
 
+// Leaderboard sorted-set members encode the entry alongside its score:
+// "userID:username" for players and "teamID:members:teamName" for teams.
+// The in-memory repository shares these encodings.
+
+func leaderboardMember(userID uuid.UUID, username string, score int) *redis.Z {
+	return &redis.Z{
+		Score:  float64(score),
+		Member: fmt.Sprintf("%s:%s", userID, username),
+	}
+}
+
+func teamLeaderboardMember(e model.TeamLeaderboardEntry) *redis.Z {
+	return &redis.Z{
+		Score:  float64(e.Score),
+		Member: fmt.Sprintf("%s:%d:%s", e.TeamID, e.Members, e.TeamName),
+	}
+}
+
+// parseLeaderboard ranks sorted-set members given highest score first.
+func parseLeaderboard(results []redis.Z) []model.LeaderboardEntry {
 	var leaderboard []model.LeaderboardEntry
 	for i, result := range results {
 		// Parse member: "userID:username"
@@ -102,35 +235,216 @@ func (r *redisRepository) GetLeaderboard(quizID string) ([]model.LeaderboardEntr
 		})
 	}
 
-	return leaderboard, nil
+	return leaderboard
+}
+
+func parseTeamLeaderboard(results []redis.Z) []model.TeamLeaderboardEntry {
+	var leaderboard []model.TeamLeaderboardEntry
+	for i, result := range results {
+		// Parse member: "teamID:members:teamName"
+		parts := strings.SplitN(result.Member.(string), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		teamID, _ := uuid.Parse(parts[0])
+		members, _ := strconv.Atoi(parts[1])
+		leaderboard = append(leaderboard, model.TeamLeaderboardEntry{
+			TeamID:   teamID,
+			TeamName: parts[2],
+			Members:  members,
+			Score:    int(result.Score),
+			Rank:     i + 1,
+		})
+	}
+
+	return leaderboard
 }
 
-func (r *redisRepository) DeleteKey(key string) error {
-	result := r.client.Del(r.ctx, key)
+func (r *redisRepository) DeleteKey(ctx context.Context, key string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result := r.client.Del(ctx, key)
 	if result.Err() != nil {
 		return result.Err()
 	}
-	log.Printf("🗑️ Deleted cache key: %s", key)
+	slog.DebugContext(ctx, "Deleted cache key", "key", key)
 	return nil
 }
 
-func (r *redisRepository) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+func (r *redisRepository) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	data, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	return r.client.Set(r.ctx, key, data, ttl).Err()
+	return r.client.Set(ctx, key, data, ttl).Err()
 }
 
-func (r *redisRepository) GetTTL(key string) (time.Duration, error) {
-	return r.client.TTL(r.ctx, key).Result()
+func (r *redisRepository) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.client.TTL(ctx, key).Result()
 }
 
-func (r *redisRepository) KeyExists(key string) (bool, error) {
-	result, err := r.client.Exists(r.ctx, key).Result()
+func (r *redisRepository) KeyExists(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result, err := r.client.Exists(ctx, key).Result()
 	return result > 0, err
 }
 
-func (r *redisRepository) FlushCache() error {
-	return r.client.FlushDB(r.ctx).Err()
+func (r *redisRepository) FlushCache(ctx context.Context) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.client.FlushDB(ctx).Err()
+}
+
+// lobbyTTL bounds how long a waiting room outlives its last activity.
+const lobbyTTL = 24 * time.Hour
+
+func (r *redisRepository) AddLobbyParticipant(ctx context.Context, quizID, userID, username string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	key := fmt.Sprintf("lobby:%s", quizID)
+	if err := r.client.HSet(ctx, key, userID, username).Err(); err != nil {
+		return err
+	}
+	return r.client.Expire(ctx, key, lobbyTTL).Err()
+}
+
+func (r *redisRepository) RemoveLobbyParticipant(ctx context.Context, quizID, userID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.client.HDel(ctx, fmt.Sprintf("lobby:%s", quizID), userID).Err()
+}
+
+func (r *redisRepository) GetLobbyParticipants(ctx context.Context, quizID string) (map[string]string, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.client.HGetAll(ctx, fmt.Sprintf("lobby:%s", quizID)).Result()
+}
+
+// TouchPresence records a heartbeat and reports whether the participant was
+// already online, i.e. their previous heartbeat is younger than ttl.
+func (r *redisRepository) TouchPresence(ctx context.Context, quizID, userID string, ttl time.Duration) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	key := fmt.Sprintf("presence:%s", quizID)
+	now := time.Now()
+
+	wasOnline := false
+	previous, err := r.client.ZScore(ctx, key, userID).Result()
+	if err == nil {
+		wasOnline = now.Sub(time.UnixMilli(int64(previous))) < ttl
+	} else if err != redis.Nil {
+		return false, err
+	}
+
+	if err := r.client.ZAdd(ctx, key, &redis.Z{Score: float64(now.UnixMilli()), Member: userID}).Err(); err != nil {
+		return false, err
+	}
+	return wasOnline, r.client.Expire(ctx, key, lobbyTTL).Err()
+}
+
+func (r *redisRepository) ClearPresence(ctx context.Context, quizID, userID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.client.ZRem(ctx, fmt.Sprintf("presence:%s", quizID), userID).Err()
+}
+
+// GetPresence returns the last heartbeat of every participant seen in a lobby.
+func (r *redisRepository) GetPresence(ctx context.Context, quizID string) (map[string]time.Time, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	results, err := r.client.ZRangeWithScores(ctx, fmt.Sprintf("presence:%s", quizID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	presence := make(map[string]time.Time, len(results))
+	for _, result := range results {
+		presence[result.Member.(string)] = time.UnixMilli(int64(result.Score))
+	}
+	return presence, nil
+}
+
+// SaveParticipantToken maps an opaque resume token to the participant it
+// was issued to.
+func (r *redisRepository) SaveParticipantToken(ctx context.Context, token string, quizID, userID string, ttl time.Duration) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	key := fmt.Sprintf("participant_token:%s", token)
+	return r.client.Set(ctx, key, quizID+":"+userID, ttl).Err()
+}
+
+func (r *redisRepository) GetParticipantToken(ctx context.Context, token string) (string, string, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	value, err := r.client.Get(ctx, fmt.Sprintf("participant_token:%s", token)).Result()
+	if err != nil {
+		return "", "", err
+	}
+
+	quizID, userID, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", "", fmt.Errorf("malformed participant token")
+	}
+	return quizID, userID, nil
+}
+
+// AcquireLock takes a best-effort distributed lock that expires after ttl. The
+// returned token identifies this holder and must be passed to ReleaseLock.
+func (r *redisRepository) AcquireLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	token, err := newLockToken()
+	if err != nil {
+		return "", false, err
+	}
+	acquired, err := r.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !acquired {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+// releaseLockScript deletes a lock only while it still holds the caller's
+// token, so a holder whose lock expired cannot free one taken since by
+// another instance.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (r *redisRepository) ReleaseLock(ctx context.Context, key, token string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return releaseLockScript.Run(ctx, r.client, []string{key}, token).Err()
+}
+
+func newLockToken() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
 }
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"quiz-app/internal/model"
+	"quiz-app/internal/repository"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// discriminationGroupRatio is the share of participants in each of the top
+// and bottom groups used for the discrimination index.
+const discriminationGroupRatio = 0.27
+
+type AnalyticsService interface {
+	GetQuizAnalytics(ctx context.Context, quizID string) (*model.QuizAnalytics, error)
+	GetQuestionAnalytics(ctx context.Context, quizID, questionID string) (*model.QuestionAnalytics, error)
+}
+
+type analyticsService struct {
+	quizRepo    repository.QuizRepository
+	quizService QuizService
+}
+
+func NewAnalyticsService(quizRepo repository.QuizRepository, quizService QuizService) AnalyticsService {
+	return &analyticsService{
+		quizRepo:    quizRepo,
+		quizService: quizService,
+	}
+}
+
+func (s *analyticsService) GetQuizAnalytics(ctx context.Context, quizID string) (*model.QuizAnalytics, error) {
+	quiz, err := s.quizService.GetQuiz(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("quiz not found")
+	}
+
+	answers, err := s.quizRepo.GetQuizAnswers(ctx, quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildQuizAnalytics(quiz, answers), nil
+}
+
+func (s *analyticsService) GetQuestionAnalytics(ctx context.Context, quizID, questionID string) (*model.QuestionAnalytics, error) {
+	questionUUID, err := uuid.Parse(questionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid question ID")
+	}
+
+	analytics, err := s.GetQuizAnalytics(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range analytics.Questions {
+		if analytics.Questions[i].QuestionID == questionUUID {
+			return &analytics.Questions[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("question not found")
+}
+
+func buildQuizAnalytics(quiz *model.QuizSession, answers []model.UserAnswer) *model.QuizAnalytics {
+	questions := make([]model.Question, len(quiz.Questions))
+	copy(questions, quiz.Questions)
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].Order < questions[j].Order
+	})
+
+	questionByID := make(map[uuid.UUID]model.Question, len(questions))
+	for _, q := range questions {
+		questionByID[q.ID] = q
+	}
+
+	// Keep the first answer each participant gave to each question
+	byUser := make(map[uuid.UUID]map[uuid.UUID]model.UserAnswer)
+	for _, a := range answers {
+		if _, ok := questionByID[a.QuestionID]; !ok {
+			continue
+		}
+		if byUser[a.UserID] == nil {
+			byUser[a.UserID] = make(map[uuid.UUID]model.UserAnswer)
+		}
+		if _, exists := byUser[a.UserID][a.QuestionID]; !exists {
+			byUser[a.UserID][a.QuestionID] = a
+		}
+	}
+
+	// Rank participants by score to find the top and bottom groups
+	type participantScore struct {
+		userID uuid.UUID
+		score  int
+	}
+	ranked := make([]participantScore, 0, len(byUser))
+	for userID, userAnswers := range byUser {
+		score := 0
+		for questionID, a := range userAnswers {
+			if a.IsCorrect {
+				score += questionByID[questionID].Points
+			}
+		}
+		ranked = append(ranked, participantScore{userID: userID, score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	groupSize := 0
+	if len(ranked) >= 2 {
+		groupSize = int(math.Round(float64(len(ranked)) * discriminationGroupRatio))
+		if groupSize < 1 {
+			groupSize = 1
+		}
+	}
+
+	analytics := &model.QuizAnalytics{
+		QuizID:       quiz.ID,
+		Title:        quiz.Title,
+		Participants: len(byUser),
+		Questions:    make([]model.QuestionAnalytics, 0, len(questions)),
+		DropOff:      make([]model.DropOffPoint, 0, len(questions)),
+	}
+
+	for _, q := range questions {
+		qa := model.QuestionAnalytics{
+			QuestionID:    q.ID,
+			Order:         q.Order,
+			QuestionText:  q.QuestionText,
+			CorrectAnswer: q.CorrectAnswer,
+		}
+
+		optionCounts := make(map[string]int)
+		var responseTimes []int
+		for _, userAnswers := range byUser {
+			a, ok := userAnswers[q.ID]
+			if !ok {
+				continue
+			}
+			qa.Responses++
+			optionCounts[a.Answer]++
+			if a.IsCorrect {
+				qa.CorrectCount++
+			}
+			if a.ResponseTimeMs > 0 {
+				responseTimes = append(responseTimes, a.ResponseTimeMs)
+			}
+		}
+
+		if qa.Responses > 0 {
+			qa.CorrectRate = float64(qa.CorrectCount) / float64(qa.Responses)
+		}
+		qa.MedianResponseTimeMs = median(responseTimes)
+
+		for _, option := range q.Options {
+			stat := model.OptionStat{Option: option, Count: optionCounts[option]}
+			if qa.Responses > 0 {
+				stat.Percentage = float64(stat.Count) * 100 / float64(qa.Responses)
+			}
+			qa.Options = append(qa.Options, stat)
+		}
+
+		if groupSize > 0 {
+			correctIn := func(group []participantScore) float64 {
+				correct := 0
+				for _, p := range group {
+					if a, ok := byUser[p.userID][q.ID]; ok && a.IsCorrect {
+						correct++
+					}
+				}
+				return float64(correct) / float64(len(group))
+			}
+			qa.DiscriminationIndex = correctIn(ranked[:groupSize]) - correctIn(ranked[len(ranked)-groupSize:])
+		}
+
+		analytics.Questions = append(analytics.Questions, qa)
+	}
+
+	// A participant reached a question if they answered it or anything after
+	// it; they dropped off after the last question they answered.
+	lastAnswered := make(map[uuid.UUID]int)
+	for userID, userAnswers := range byUser {
+		for questionID := range userAnswers {
+			if order := questionByID[questionID].Order; order > lastAnswered[userID] {
+				lastAnswered[userID] = order
+			}
+		}
+	}
+
+	for i, q := range questions {
+		point := model.DropOffPoint{QuestionID: q.ID, Order: q.Order}
+		for _, last := range lastAnswered {
+			if last >= q.Order {
+				point.Reached++
+			}
+			if last == q.Order && i < len(questions)-1 {
+				point.DroppedAfter++
+			}
+		}
+		analytics.DropOff = append(analytics.DropOff, point)
+	}
+
+	return analytics
+}
+
+func median(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+	return float64(sorted[mid])
+}
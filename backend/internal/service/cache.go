@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"quiz-app/internal/model"
+	"quiz-app/internal/repository"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheAside is the read policy for one kind of Redis-cached value. On a
+// miss, concurrent callers for the same key share a single database load.
+// With a stale window, an entry whose TTL has run down into that window is
+// still served, and one background load refreshes it.
+type cacheAside struct {
+	name        string
+	redisRepo   repository.RedisRepository
+	staleWindow time.Duration
+	spawn       func(ctx context.Context, fn func(ctx context.Context))
+	group       singleflight.Group
+
+	hits      atomic.Uint64
+	staleHits atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+func newCacheAside(name string, redisRepo repository.RedisRepository, staleWindow time.Duration, spawn func(ctx context.Context, fn func(ctx context.Context))) *cacheAside {
+	return &cacheAside{name: name, redisRepo: redisRepo, staleWindow: staleWindow, spawn: spawn}
+}
+
+// fetchCached returns the value read from the cache under key, or else the
+// result of load, which is expected to repopulate the cache. Loads run
+// without the caller's cancellation because other callers may be waiting on
+// them. Coalesced callers receive the same value and must not modify it.
+func fetchCached[T any](ctx context.Context, c *cacheAside, key string, read func(ctx context.Context) (T, bool), load func(ctx context.Context) (T, error)) (T, error) {
+	if value, ok := read(ctx); ok {
+		if c.isStale(ctx, key) {
+			c.staleHits.Add(1)
+			c.spawn(ctx, func(ctx context.Context) {
+				c.group.Do(key, func() (interface{}, error) {
+					return load(ctx)
+				})
+			})
+		} else {
+			c.hits.Add(1)
+		}
+		return value, nil
+	}
+
+	c.misses.Add(1)
+	result, err, shared := c.group.Do(key, func() (interface{}, error) {
+		return load(context.WithoutCancel(ctx))
+	})
+	if shared {
+		c.coalesced.Add(1)
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return result.(T), nil
+}
+
+// isStale reports whether key has less than the stale window left to live.
+func (c *cacheAside) isStale(ctx context.Context, key string) bool {
+	if c.staleWindow <= 0 {
+		return false
+	}
+	ttl, err := c.redisRepo.GetTTL(ctx, key)
+	return err == nil && ttl >= 0 && ttl <= c.staleWindow
+}
+
+func (c *cacheAside) stats() model.CacheStats {
+	return model.CacheStats{
+		Name:      c.name,
+		Hits:      c.hits.Load(),
+		StaleHits: c.staleHits.Load(),
+		Misses:    c.misses.Load(),
+		Coalesced: c.coalesced.Load(),
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"quiz-app/internal/config"
+	"quiz-app/internal/repository"
+	"time"
+)
+
+const defaultCleanupInterval = time.Minute
+
+// CleanupWorker periodically expires quizzes and self-paced attempts, and
+// applies the retention policy to quizzes that completed long enough ago.
+type CleanupWorker struct {
+	quizService QuizService
+	quizRepo    repository.QuizRepository
+	cfg         config.Cleanup
+}
+
+func NewCleanupWorker(quizService QuizService, quizRepo repository.QuizRepository, cfg config.Cleanup) *CleanupWorker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultCleanupInterval
+	}
+	return &CleanupWorker{
+		quizService: quizService,
+		quizRepo:    quizRepo,
+		cfg:         cfg,
+	}
+}
+
+// Run blocks until ctx is cancelled, running a cleanup pass every interval.
+func (w *CleanupWorker) Run(ctx context.Context) {
+	slog.Info("Cleanup worker started", "interval", w.cfg.Interval)
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	w.runOnce(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Cleanup worker stopped")
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+func (w *CleanupWorker) runOnce(ctx context.Context) {
+	if _, err := w.quizService.FinalizeExpiredAttempts(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to finalize expired attempts", "error", err)
+	}
+
+	if _, err := w.quizService.ExpireQuizzes(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to expire quizzes", "error", err)
+	}
+
+	if err := w.applyRetention(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to apply retention policy", "error", err)
+	}
+}
+
+func (w *CleanupWorker) applyRetention(ctx context.Context) error {
+	if w.cfg.Retention <= 0 || (w.cfg.RetentionAction != "archive" && w.cfg.RetentionAction != "delete") {
+		return nil
+	}
+
+	// Deleting also clears quizzes an earlier archive policy left behind
+	statuses := []string{"completed"}
+	if w.cfg.RetentionAction == "delete" {
+		statuses = append(statuses, "archived")
+	}
+	ids, err := w.quizRepo.GetQuizIDsCompletedBefore(ctx, statuses, time.Now().Add(-w.cfg.Retention))
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	if w.cfg.RetentionAction == "archive" {
+		if err := w.quizRepo.ArchiveQuizzes(ctx, ids); err != nil {
+			return err
+		}
+	} else if err := w.quizRepo.DeleteQuizzes(ctx, ids); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := w.quizService.PurgeQuizCache(ctx, id.String()); err != nil {
+			slog.WarnContext(ctx, "Failed to purge quiz cache", "quiz_id", id, "error", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Applied retention policy", "action", w.cfg.RetentionAction, "quizzes", len(ids))
+	return nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"quiz-app/internal/config"
+	"quiz-app/internal/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestRetentionDeleteAgesQuizzesByCompletion(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	longAgo := time.Now().Add(-48 * time.Hour)
+	recently := time.Now().Add(-time.Minute)
+	// Archived by an earlier policy, and expired long ago but completed just now
+	archived := &model.QuizSession{ID: uuid.New(), Title: "Archived", Status: "archived", CreatedAt: longAgo, ExpiresAt: longAgo, CompletedAt: &longAgo}
+	late := &model.QuizSession{ID: uuid.New(), Title: "Late", Status: "completed", CreatedAt: longAgo, ExpiresAt: longAgo, CompletedAt: &recently}
+	for _, quiz := range []*model.QuizSession{archived, late} {
+		if err := s.quizRepo.CreateQuiz(ctx, quiz); err != nil {
+			t.Fatalf("create quiz: %v", err)
+		}
+	}
+
+	w := NewCleanupWorker(s.quiz, s.quizRepo, config.Cleanup{Retention: 24 * time.Hour, RetentionAction: "delete"})
+	if err := w.applyRetention(ctx); err != nil {
+		t.Fatalf("apply retention: %v", err)
+	}
+
+	if _, err := s.quizRepo.GetQuiz(ctx, archived.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("archived quiz after retention = %v, want deleted", err)
+	}
+	if _, err := s.quizRepo.GetQuiz(ctx, late.ID); err != nil {
+		t.Fatalf("recently completed quiz after retention = %v, want kept", err)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"quiz-app/internal/model"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readinessCheckTimeout bounds each dependency probe, so a hung database or
+// Redis makes /readyz fail rather than hang the orchestrator's probe.
+const readinessCheckTimeout = 2 * time.Second
+
+// HealthCheck probes one dependency the server needs to serve requests.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthService decides whether the server is ready for traffic. Once
+// shutdown has begun it reports not ready without probing anything.
+type HealthService interface {
+	Readiness(ctx context.Context) (*model.Readiness, bool)
+	BeginShutdown()
+}
+
+type healthService struct {
+	checks       []HealthCheck
+	shuttingDown atomic.Bool
+}
+
+func NewHealthService(checks ...HealthCheck) HealthService {
+	return &healthService{checks: checks}
+}
+
+// Readiness runs every check concurrently and reports whether all passed.
+func (s *healthService) Readiness(ctx context.Context) (*model.Readiness, bool) {
+	if s.shuttingDown.Load() {
+		return &model.Readiness{Status: "shutting_down"}, false
+	}
+
+	results := make([]model.DependencyCheck, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			results[i] = model.DependencyCheck{
+				Name:      check.Name,
+				Healthy:   err == nil,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, result := range results {
+		ready = ready && result.Healthy
+	}
+	status := "ready"
+	if !ready {
+		status = "not_ready"
+	}
+	return &model.Readiness{Status: status, Checks: results}, ready
+}
+
+func (s *healthService) BeginShutdown() {
+	s.shuttingDown.Store(true)
+}
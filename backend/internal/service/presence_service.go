@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"quiz-app/internal/config"
+	"quiz-app/internal/model"
+	"quiz-app/internal/repository"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultHeartbeatTTL   = 30 * time.Second
+	defaultSweepInterval  = 5 * time.Second
+	defaultReconnectGrace = 15 * time.Second
+)
+
+// PresenceService tracks who is in a quiz's waiting room. The roster and
+// heartbeats live in Redis so every instance sees the same lobby; roster
+// changes are pushed to the lobby WebSocket channel.
+type PresenceService interface {
+	Join(ctx context.Context, quizID string, user *model.User) error
+	Leave(ctx context.Context, quizID, userID string) error
+	IsMember(ctx context.Context, quizID, userID string) bool
+	Heartbeat(ctx context.Context, quizID, userID string) error
+	Disconnect(ctx context.Context, quizID, userID string)
+	GetRoster(ctx context.Context, quizID string) ([]model.LobbyParticipant, error)
+	BroadcastRoster(ctx context.Context, quizID, event string, userID string)
+	WatchLobby(quizID string) (release func())
+	Run(ctx context.Context)
+}
+
+type presenceService struct {
+	redisRepo repository.RedisRepository
+	wsService WebSocketService
+	cfg       config.Presence
+
+	// Lobbies watched from this instance, with the online set last pushed
+	mu         sync.Mutex
+	watchers   map[string]int
+	lastOnline map[string]map[uuid.UUID]bool
+}
+
+func NewPresenceService(redisRepo repository.RedisRepository, wsService WebSocketService, cfg config.Presence) PresenceService {
+	if cfg.HeartbeatTTL <= 0 {
+		cfg.HeartbeatTTL = defaultHeartbeatTTL
+	}
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = defaultSweepInterval
+	}
+	if cfg.ReconnectGrace <= 0 {
+		cfg.ReconnectGrace = defaultReconnectGrace
+	}
+	return &presenceService{
+		redisRepo:  redisRepo,
+		wsService:  wsService,
+		cfg:        cfg,
+		watchers:   make(map[string]int),
+		lastOnline: make(map[string]map[uuid.UUID]bool),
+	}
+}
+
+func (s *presenceService) Join(ctx context.Context, quizID string, user *model.User) error {
+	if err := s.redisRepo.AddLobbyParticipant(ctx, quizID, user.ID.String(), user.Username); err != nil {
+		return err
+	}
+	s.BroadcastRoster(ctx, quizID, "joined", user.ID.String())
+	return nil
+}
+
+func (s *presenceService) Leave(ctx context.Context, quizID, userID string) error {
+	if !s.IsMember(ctx, quizID, userID) {
+		return fmt.Errorf("participant not in lobby")
+	}
+	if err := s.redisRepo.RemoveLobbyParticipant(ctx, quizID, userID); err != nil {
+		return err
+	}
+	if err := s.redisRepo.ClearPresence(ctx, quizID, userID); err != nil {
+		return err
+	}
+	s.BroadcastRoster(ctx, quizID, "left", userID)
+	return nil
+}
+
+func (s *presenceService) IsMember(ctx context.Context, quizID, userID string) bool {
+	participants, err := s.redisRepo.GetLobbyParticipants(ctx, quizID)
+	if err != nil {
+		return false
+	}
+	_, ok := participants[userID]
+	return ok
+}
+
+func (s *presenceService) Heartbeat(ctx context.Context, quizID, userID string) error {
+	if !s.IsMember(ctx, quizID, userID) {
+		return fmt.Errorf("participant not in lobby")
+	}
+
+	wasOnline, err := s.redisRepo.TouchPresence(ctx, quizID, userID, s.cfg.HeartbeatTTL)
+	if err != nil {
+		return err
+	}
+	if !wasOnline {
+		s.BroadcastRoster(ctx, quizID, "online", userID)
+	}
+	return nil
+}
+
+// Disconnect marks a participant offline once ReconnectGrace has passed,
+// unless they sent a heartbeat (e.g. by reconnecting) in the meantime.
+func (s *presenceService) Disconnect(ctx context.Context, quizID, userID string) {
+	if !s.IsMember(ctx, quizID, userID) {
+		return
+	}
+
+	disconnectedAt := time.Now()
+	ctx = context.WithoutCancel(ctx)
+	time.AfterFunc(s.cfg.ReconnectGrace, func() {
+		presence, err := s.redisRepo.GetPresence(ctx, quizID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to load presence", "quiz_id", quizID, "error", err)
+			return
+		}
+		if lastSeen, ok := presence[userID]; ok && lastSeen.After(disconnectedAt) {
+			return
+		}
+
+		if err := s.redisRepo.ClearPresence(ctx, quizID, userID); err != nil {
+			slog.WarnContext(ctx, "Failed to clear presence", "quiz_id", quizID, "user_id", userID, "error", err)
+			return
+		}
+		s.BroadcastRoster(ctx, quizID, "offline", userID)
+	})
+}
+
+func (s *presenceService) GetRoster(ctx context.Context, quizID string) ([]model.LobbyParticipant, error) {
+	participants, err := s.redisRepo.GetLobbyParticipants(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	presence, err := s.redisRepo.GetPresence(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	roster := make([]model.LobbyParticipant, 0, len(participants))
+	for id, username := range participants {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+
+		entry := model.LobbyParticipant{UserID: userID, Username: username}
+		if lastSeen, ok := presence[id]; ok {
+			entry.LastSeen = &lastSeen
+			entry.Online = now.Sub(lastSeen) < s.cfg.HeartbeatTTL
+		}
+		roster = append(roster, entry)
+	}
+
+	sort.Slice(roster, func(i, j int) bool {
+		return roster[i].Username < roster[j].Username
+	})
+
+	return roster, nil
+}
+
+// BroadcastRoster pushes the current roster to lobby viewers on this
+// instance, tagged with the event that triggered it.
+func (s *presenceService) BroadcastRoster(ctx context.Context, quizID, event string, userID string) {
+	if !s.wsService.HasLobbyViewers(quizID) {
+		return
+	}
+
+	roster, err := s.GetRoster(ctx, quizID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load lobby roster", "quiz_id", quizID, "error", err)
+		return
+	}
+	s.rememberOnline(quizID, roster)
+
+	update := model.LobbyUpdate{
+		Type:         "lobby_update",
+		Event:        event,
+		Participants: roster,
+		UpdatedAt:    time.Now(),
+	}
+	if id, err := uuid.Parse(userID); err == nil {
+		update.UserID = &id
+	}
+
+	s.wsService.BroadcastLobbyUpdate(quizID, update)
+}
+
+// WatchLobby marks a lobby as watched from this instance so the sweeper
+// notices participants whose heartbeats expire. Call release on disconnect.
+func (s *presenceService) WatchLobby(quizID string) func() {
+	s.mu.Lock()
+	s.watchers[quizID]++
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.watchers[quizID]--
+			if s.watchers[quizID] <= 0 {
+				delete(s.watchers, quizID)
+				delete(s.lastOnline, quizID)
+			}
+		})
+	}
+}
+
+// Run blocks until ctx is cancelled, rebroadcasting rosters whose online
+// state changed because heartbeats timed out.
+func (s *presenceService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *presenceService) sweep(ctx context.Context) {
+	s.mu.Lock()
+	quizIDs := make([]string, 0, len(s.watchers))
+	for quizID := range s.watchers {
+		quizIDs = append(quizIDs, quizID)
+	}
+	s.mu.Unlock()
+
+	for _, quizID := range quizIDs {
+		roster, err := s.GetRoster(ctx, quizID)
+		if err != nil {
+			continue
+		}
+		if s.onlineChanged(quizID, roster) {
+			s.BroadcastRoster(ctx, quizID, "roster", "")
+		}
+	}
+}
+
+func (s *presenceService) rememberOnline(quizID string, roster []model.LobbyParticipant) {
+	online := make(map[uuid.UUID]bool, len(roster))
+	for _, p := range roster {
+		online[p.UserID] = p.Online
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, watched := s.watchers[quizID]; watched {
+		s.lastOnline[quizID] = online
+	}
+}
+
+func (s *presenceService) onlineChanged(quizID string, roster []model.LobbyParticipant) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.lastOnline[quizID]
+	if len(last) != len(roster) {
+		return true
+	}
+	for _, p := range roster {
+		if online, ok := last[p.UserID]; !ok || online != p.Online {
+			return true
+		}
+	}
+	return false
+}
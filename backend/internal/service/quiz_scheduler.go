@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"quiz-app/internal/config"
+	"quiz-app/internal/model"
+	"quiz-app/internal/repository"
+	"time"
+)
+
+const (
+	defaultSchedulerInterval = time.Second
+	defaultCountdownWindow   = 10 * time.Minute
+	defaultStartLockTTL      = 30 * time.Second
+)
+
+// QuizScheduler opens quizzes at their StartsAt time and pushes a countdown to
+// the waiting room beforehand. Schedules live in the database, so overdue
+// quizzes are started after a restart; a Redis lock keeps several instances
+// from starting the same quiz.
+type QuizScheduler struct {
+	quizService QuizService
+	quizRepo    repository.QuizRepository
+	redisRepo   repository.RedisRepository
+	wsService   WebSocketService
+	cfg         config.Scheduler
+
+	// starting holds due quizzes that another instance was still starting,
+	// keyed by ID. Only the Run goroutine touches it.
+	starting map[string]model.QuizSession
+}
+
+func NewQuizScheduler(quizService QuizService, quizRepo repository.QuizRepository, redisRepo repository.RedisRepository, wsService WebSocketService, cfg config.Scheduler) *QuizScheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultSchedulerInterval
+	}
+	if cfg.CountdownWindow <= 0 {
+		cfg.CountdownWindow = defaultCountdownWindow
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = defaultStartLockTTL
+	}
+	return &QuizScheduler{
+		quizService: quizService,
+		quizRepo:    quizRepo,
+		redisRepo:   redisRepo,
+		wsService:   wsService,
+		cfg:         cfg,
+		starting:    make(map[string]model.QuizSession),
+	}
+}
+
+// Run blocks until ctx is cancelled, checking scheduled quizzes every interval.
+func (s *QuizScheduler) Run(ctx context.Context) {
+	slog.Info("Quiz scheduler started", "interval", s.cfg.Interval)
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Quiz scheduler stopped")
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+func (s *QuizScheduler) tick(ctx context.Context) {
+	// Checked before loading schedules, so a quiz seen active here is no
+	// longer among them and is announced once
+	for _, quiz := range s.starting {
+		s.announceStart(ctx, quiz)
+	}
+
+	now := time.Now()
+	quizzes, err := s.quizRepo.GetScheduledQuizzes(ctx, now.Add(s.cfg.CountdownWindow))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load scheduled quizzes", "error", err)
+		return
+	}
+
+	for _, quiz := range quizzes {
+		quizID := quiz.ID.String()
+		remaining := quiz.StartsAt.Sub(now)
+
+		if remaining > 0 {
+			// Every instance pushes the countdown to its own viewers
+			if s.wsService.HasLeaderboardViewers(quizID) || s.wsService.HasLobbyViewers(quizID) {
+				s.wsService.BroadcastQuizEvent(quizID, "quiz_countdown", model.QuizCountdown{
+					QuizID:           quiz.ID,
+					StartsAt:         *quiz.StartsAt,
+					SecondsRemaining: int(math.Ceil(remaining.Seconds())),
+				})
+			}
+			continue
+		}
+
+		if err := s.start(ctx, quizID); err != nil {
+			slog.ErrorContext(ctx, "Failed to start scheduled quiz", "quiz_id", quizID, "error", err)
+			continue
+		}
+		s.announceStart(ctx, quiz)
+	}
+}
+
+// announceStart tells viewers on this instance that quiz is open once it is
+// active, whichever instance started it. A quiz another instance is still
+// starting is checked again on the next tick, as it may then no longer be
+// scheduled.
+func (s *QuizScheduler) announceStart(ctx context.Context, quiz model.QuizSession) {
+	quizID := quiz.ID.String()
+	current, err := s.quizRepo.GetQuiz(ctx, quiz.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check scheduled quiz", "quiz_id", quizID, "error", err)
+		delete(s.starting, quizID)
+		return
+	}
+
+	switch current.Status {
+	case "waiting":
+		s.starting[quizID] = quiz
+	case "active":
+		delete(s.starting, quizID)
+		s.wsService.BroadcastQuizEvent(quizID, "quiz_started", model.QuizCountdown{
+			QuizID:   quiz.ID,
+			StartsAt: *quiz.StartsAt,
+		})
+	default:
+		delete(s.starting, quizID)
+	}
+}
+
+func (s *QuizScheduler) start(ctx context.Context, quizID string) error {
+	lockKey := fmt.Sprintf("lock:quiz_start:%s", quizID)
+	token, acquired, err := s.redisRepo.AcquireLock(ctx, lockKey, s.cfg.LockTTL)
+	if err != nil || !acquired {
+		return err
+	}
+	defer s.redisRepo.ReleaseLock(ctx, lockKey, token)
+
+	quiz, err := s.quizService.GetQuiz(ctx, quizID)
+	if err != nil {
+		return err
+	}
+
+	// The conditional update guards against a start that already happened
+	// while the lock was free
+	started, err := s.quizRepo.StartScheduledQuiz(ctx, quiz.ID)
+	if err != nil || !started {
+		return err
+	}
+
+	if err := s.quizService.InvalidateQuizCache(ctx, quizID); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate quiz cache", "quiz_id", quizID, "error", err)
+	}
+
+	slog.InfoContext(ctx, "Scheduled quiz started", "quiz_id", quizID)
+	return nil
+}
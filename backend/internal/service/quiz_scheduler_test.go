@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"quiz-app/internal/config"
+	"quiz-app/internal/model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// recordingWebSocketService records quiz events instead of sending them.
+type recordingWebSocketService struct {
+	WebSocketService
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (s *recordingWebSocketService) BroadcastQuizEvent(quizID string, eventType string, data any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, eventType)
+}
+
+func (s *recordingWebSocketService) count(eventType string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, event := range s.events {
+		if event == eventType {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSchedulerAnnouncesStartOnlyOnceActive(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+	ws := &recordingWebSocketService{WebSocketService: NewWebSocketService(s.redisRepo)}
+	scheduler := NewQuizScheduler(s.quiz, s.quizRepo, s.redisRepo, ws, config.Scheduler{})
+
+	startsAt := time.Now().Add(-time.Second)
+	quiz := &model.QuizSession{
+		ID:        uuid.New(),
+		Title:     "Scheduled",
+		Status:    "waiting",
+		Mode:      "live",
+		CreatedAt: time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+		StartsAt:  &startsAt,
+	}
+	if err := s.quizRepo.CreateQuiz(ctx, quiz); err != nil {
+		t.Fatalf("create quiz: %v", err)
+	}
+
+	// Another instance holds the start lock and has not started the quiz yet
+	lockKey := "lock:quiz_start:" + quiz.ID.String()
+	token, acquired, err := s.redisRepo.AcquireLock(ctx, lockKey, time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("acquire lock: %v, %v", acquired, err)
+	}
+	scheduler.tick(ctx)
+	if n := ws.count("quiz_started"); n != 0 {
+		t.Fatalf("quiz_started sent %d times before the quiz was active", n)
+	}
+
+	if _, err := s.quizRepo.StartScheduledQuiz(ctx, quiz.ID); err != nil {
+		t.Fatalf("start quiz: %v", err)
+	}
+	if err := s.redisRepo.ReleaseLock(ctx, lockKey, token); err != nil {
+		t.Fatalf("release lock: %v", err)
+	}
+	scheduler.tick(ctx)
+	scheduler.tick(ctx)
+	if n := ws.count("quiz_started"); n != 1 {
+		t.Fatalf("quiz_started sent %d times, want once", n)
+	}
+}
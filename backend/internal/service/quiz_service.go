@@ -1,49 +1,192 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"quiz-app/internal/config"
+	"quiz-app/internal/metrics"
 	"quiz-app/internal/model"
 	"quiz-app/internal/repository"
+	"quiz-app/internal/tracing"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
 )
 
+// defaultQuizLifetime is how long a quiz stays open after it opens when no
+// expires_at is given.
+const defaultQuizLifetime = 24 * time.Hour
+
+// expireLockTTL bounds how long one instance may hold a quiz's expiry lock,
+// which covers freezing its results.
+const expireLockTTL = 30 * time.Second
+
+// ErrReviewNotAvailable is returned when the quiz's review visibility does not
+// allow answers to be revealed yet.
+var ErrReviewNotAvailable = errors.New("review is not available for this quiz")
+
+// ErrInvalidParticipantToken is returned when a resume token is unknown,
+// expired or was issued for another quiz.
+var ErrInvalidParticipantToken = errors.New("invalid participant token")
+
 type QuizService interface {
-	CreateQuiz(req *model.CreateQuizRequest) (*model.QuizSession, error)
-	JoinQuiz(quizID string, req *model.JoinQuizRequest) (*model.User, error)
-	SubmitAnswer(userID, quizID string, req *model.SubmitAnswerRequest) (*model.SubmitAnswerResponse, error)
-	GetLeaderboard(quizID string) ([]model.LeaderboardEntry, error)
-	GetQuiz(quizID string) (*model.QuizSession, error)
+	CreateQuiz(ctx context.Context, req *model.CreateQuizRequest) (*model.QuizSession, error)
+	JoinQuiz(ctx context.Context, quizID string, req *model.JoinQuizRequest) (*model.User, string, error)
+	ResumeParticipant(ctx context.Context, quizID, token string) (*model.ResumeState, error)
+	SubmitAnswer(ctx context.Context, userID, quizID string, req *model.SubmitAnswerRequest) (*model.SubmitAnswerResponse, error)
+	GetLeaderboard(ctx context.Context, quizID string) ([]model.LeaderboardEntry, error)
+	GetQuiz(ctx context.Context, quizID string) (*model.QuizSession, error)
+	UpdateQuizStatus(ctx context.Context, quizID string, status string) (*model.QuizSession, error)
+	GetParticipantStats(ctx context.Context, userID, quizID string) (*model.ParticipantStats, error)
+	StartAttempt(ctx context.Context, userID, quizID string) (*model.QuizAttempt, error)
+	GetAttempt(ctx context.Context, userID, quizID string) (*model.QuizAttempt, error)
+	SubmitAttempt(ctx context.Context, userID, quizID string) (*model.QuizAttempt, error)
+	ListAttempts(ctx context.Context, userID, quizID string) ([]model.QuizAttempt, error)
+	FinalizeExpiredAttempts(ctx context.Context) (int64, error)
+	ExpireQuizzes(ctx context.Context) (int, error)
+	GetQuizReview(ctx context.Context, userID, quizID string) (*model.QuizReview, error)
+	GetQuizResults(ctx context.Context, quizID string) ([]model.QuizResult, error)
+	GetTeams(ctx context.Context, quizID string) ([]model.Team, error)
+	GetTeamLeaderboard(ctx context.Context, quizID string) ([]model.TeamLeaderboardEntry, error)
+	GetUserHistory(ctx context.Context, userID string) ([]model.UserQuizResult, error)
+	GetRecentWinners(ctx context.Context, limit int) ([]model.UserQuizResult, error)
 
 	// New methods for cache management
-	InvalidateQuizCache(quizID string) error
-	InvalidateLeaderboardCache(quizID string) error
-	WarmupCache(quizID string) error
+	InvalidateQuizCache(ctx context.Context, quizID string) error
+	InvalidateLeaderboardCache(ctx context.Context, quizID string) error
+	PurgeQuizCache(ctx context.Context, quizID string) error
+	InspectQuizCache(ctx context.Context, quizID string) ([]model.CacheEntry, error)
+	CacheStats() []model.CacheStats
+	WarmupCache(ctx context.Context, quizID string) error
+	WarmupActiveQuizzes(ctx context.Context) (int, error)
+
+	// Drain waits for background broadcasts and cache updates to finish
+	Drain(ctx context.Context) error
 }
 
 type quizService struct {
-	quizRepo  repository.QuizRepository
-	redisRepo repository.RedisRepository
-	wsService WebSocketService
+	quizRepo        repository.QuizRepository
+	redisRepo       repository.RedisRepository
+	wsService       WebSocketService
+	seriesService   SeriesService
+	presenceService PresenceService
+
+	// Read policies for the quiz and leaderboard caches
+	quizCache            *cacheAside
+	leaderboardCache     *cacheAside
+	teamLeaderboardCache *cacheAside
+
+	// Fire-and-forget work started by requests, waited on at shutdown
+	tasks sync.WaitGroup
 }
 
-func NewQuizService(quizRepo repository.QuizRepository, redisRepo repository.RedisRepository, wsService WebSocketService) QuizService {
-	return &quizService{
-		quizRepo:  quizRepo,
-		redisRepo: redisRepo,
-		wsService: wsService,
+func NewQuizService(quizRepo repository.QuizRepository, redisRepo repository.RedisRepository, wsService WebSocketService, seriesService SeriesService, presenceService PresenceService, cacheCfg config.Redis) QuizService {
+	s := &quizService{
+		quizRepo:        quizRepo,
+		redisRepo:       redisRepo,
+		wsService:       wsService,
+		seriesService:   seriesService,
+		presenceService: presenceService,
 	}
+	s.quizCache = newCacheAside("quiz", redisRepo, cacheCfg.StaleWhileRevalidate, s.background)
+	s.leaderboardCache = newCacheAside("leaderboard", redisRepo, cacheCfg.StaleWhileRevalidate, s.background)
+	s.teamLeaderboardCache = newCacheAside("team_leaderboard", redisRepo, cacheCfg.StaleWhileRevalidate, s.background)
+	return s
 }
 
-func (s *quizService) CreateQuiz(req *model.CreateQuizRequest) (*model.QuizSession, error) {
+func (s *quizService) CreateQuiz(ctx context.Context, req *model.CreateQuizRequest) (*model.QuizSession, error) {
 	quiz := &model.QuizSession{
-		ID:        uuid.New(),
-		Title:     req.Title,
-		Status:    "waiting",
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ID:               uuid.New(),
+		Title:            req.Title,
+		Status:           "waiting",
+		ReviewVisibility: req.ReviewVisibility,
+		CreatedAt:        time.Now(),
+	}
+	if quiz.ReviewVisibility == "" {
+		quiz.ReviewVisibility = "after_quiz"
+	}
+
+	quiz.Mode = req.Mode
+	if quiz.Mode == "" {
+		quiz.Mode = "live"
+	}
+	quiz.TimeLimitSeconds = req.TimeLimitSeconds
+	quiz.MaxAttempts = req.MaxAttempts
+	if quiz.MaxAttempts == 0 {
+		quiz.MaxAttempts = 1
+	}
+	quiz.ScoringPolicy = req.ScoringPolicy
+	if quiz.ScoringPolicy == "" {
+		quiz.ScoringPolicy = "best"
+	}
+	// A quiz stays open for a day from when it opens unless told otherwise
+	opensAt := quiz.CreatedAt
+	if req.StartsAt != nil {
+		opensAt = *req.StartsAt
+	}
+	quiz.ExpiresAt = opensAt.Add(defaultQuizLifetime)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(quiz.CreatedAt) {
+			return nil, fmt.Errorf("expires_at must be in the future")
+		}
+		quiz.ExpiresAt = *req.ExpiresAt
+	}
+	if req.StartsAt != nil {
+		if !req.StartsAt.After(quiz.CreatedAt) {
+			return nil, fmt.Errorf("starts_at must be in the future")
+		}
+		if !req.StartsAt.Before(quiz.ExpiresAt) {
+			return nil, fmt.Errorf("starts_at must be before expires_at")
+		}
+		quiz.StartsAt = req.StartsAt
+	}
+
+	quiz.TeamMode = req.TeamMode
+	if quiz.TeamMode == "" {
+		quiz.TeamMode = "none"
+		if len(req.Teams) > 0 {
+			quiz.TeamMode = "predefined"
+		}
+	}
+	quiz.TeamScoring = req.TeamScoring
+	if quiz.TeamScoring == "" {
+		quiz.TeamScoring = "sum"
+	}
+	if quiz.TeamMode == "predefined" && len(req.Teams) == 0 {
+		return nil, fmt.Errorf("predefined team mode requires at least one team")
+	}
+	seenTeams := make(map[string]bool)
+	for _, name := range req.Teams {
+		if seenTeams[name] {
+			continue
+		}
+		seenTeams[name] = true
+		quiz.Teams = append(quiz.Teams, model.Team{
+			ID:            uuid.New(),
+			QuizSessionID: quiz.ID,
+			Name:          name,
+			CreatedAt:     quiz.CreatedAt,
+		})
+	}
+
+	if req.SeriesID != "" {
+		seriesUUID, err := uuid.Parse(req.SeriesID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid series ID")
+		}
+		if _, err := s.quizRepo.GetSeries(ctx, seriesUUID); err != nil {
+			return nil, fmt.Errorf("series not found")
+		}
+		quiz.SeriesID = &seriesUUID
 	}
 
 	// Create questions
@@ -64,30 +207,72 @@ func (s *quizService) CreateQuiz(req *model.CreateQuizRequest) (*model.QuizSessi
 	}
 
 	// Save to database
-	if err := s.quizRepo.CreateQuiz(quiz); err != nil {
+	if err := s.quizRepo.CreateQuiz(ctx, quiz); err != nil {
 		return nil, err
 	}
 
 	// Cache in Redis
-	s.redisRepo.SetQuizSession(quiz.ID.String(), quiz)
+	s.redisRepo.SetQuizSession(ctx, quiz.ID.String(), quiz)
 
 	return quiz, nil
 }
 
-func (s *quizService) JoinQuiz(quizID string, req *model.JoinQuizRequest) (*model.User, error) {
+// JoinQuiz returns the participant along with a token they can later use to
+// resume the quiz after a reload or dropped connection.
+func (s *quizService) JoinQuiz(ctx context.Context, quizID string, req *model.JoinQuizRequest) (*model.User, string, error) {
 	// Check if quiz exists
 	quizUUID, err := uuid.Parse(quizID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid quiz ID")
+		return nil, "", fmt.Errorf("invalid quiz ID")
 	}
 
-	_, err = s.quizRepo.GetQuiz(quizUUID)
+	quiz, err := s.quizRepo.GetQuiz(ctx, quizUUID)
 	if err != nil {
-		return nil, fmt.Errorf("quiz not found")
+		return nil, "", fmt.Errorf("quiz not found")
+	}
+
+	var team *model.Team
+	if quiz.TeamMode != "" && quiz.TeamMode != "none" {
+		if team, err = s.resolveTeam(ctx, quiz, req.Team); err != nil {
+			return nil, "", err
+		}
+	}
+
+	user, err := s.getOrCreateUser(ctx, req.Username)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if team != nil {
+		member := &model.TeamMember{
+			QuizSessionID: quiz.ID,
+			UserID:        user.ID,
+			TeamID:        team.ID,
+			JoinedAt:      time.Now(),
+		}
+		if err := s.quizRepo.SetTeamMember(ctx, member); err != nil {
+			return nil, "", err
+		}
+		s.background(ctx, func(ctx context.Context) { s.updateAndBroadcastTeamLeaderboard(ctx, quizID) })
+	}
+
+	if quiz.Status == "waiting" {
+		if err := s.presenceService.Join(ctx, quizID, user); err != nil {
+			slog.WarnContext(ctx, "Failed to add participant to lobby", "quiz_id", quizID, "username", user.Username, "error", err)
+		}
+	}
+
+	token, err := s.issueParticipantToken(ctx, quiz, user)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to issue participant token: %w", err)
 	}
 
+	return user, token, nil
+}
+
+func (s *quizService) getOrCreateUser(ctx context.Context, username string) (*model.User, error) {
 	// Check if user already exists
-	existingUser, err := s.quizRepo.GetUserByUsername(req.Username)
+	existingUser, err := s.quizRepo.GetUserByUsername(ctx, username)
 	if err == nil {
 		return existingUser, nil
 	}
@@ -95,28 +280,85 @@ func (s *quizService) JoinQuiz(quizID string, req *model.JoinQuizRequest) (*mode
 	// Create new user
 	user := &model.User{
 		ID:        uuid.New(),
-		Username:  req.Username,
+		Username:  username,
 		CreatedAt: time.Now(),
 	}
 
-	if err := s.quizRepo.CreateUser(user); err != nil {
+	if err := s.quizRepo.CreateUser(ctx, user); err != nil {
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (s *quizService) SubmitAnswer(userID, quizID string, req *model.SubmitAnswerRequest) (*model.SubmitAnswerResponse, error) {
+// resolveTeam finds the team a participant asked to join. Self-select quizzes
+// create the team on first use; predefined quizzes only accept the host's teams.
+func (s *quizService) resolveTeam(ctx context.Context, quiz *model.QuizSession, name string) (*model.Team, error) {
+	if name == "" {
+		return nil, fmt.Errorf("team is required for this quiz")
+	}
+
+	team, err := s.quizRepo.GetTeamByName(ctx, quiz.ID, name)
+	if err == nil {
+		return team, nil
+	}
+	if quiz.TeamMode != "self_select" {
+		return nil, fmt.Errorf("team not found")
+	}
+
+	team = &model.Team{
+		ID:            uuid.New(),
+		QuizSessionID: quiz.ID,
+		Name:          name,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.quizRepo.CreateTeam(ctx, team); err != nil {
+		// Another participant may have created the same team concurrently
+		if existing, getErr := s.quizRepo.GetTeamByName(ctx, quiz.ID, name); getErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	// The cached quiz lists its teams
+	if err := s.InvalidateQuizCache(ctx, quiz.ID.String()); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate quiz cache", "quiz_id", quiz.ID, "error", err)
+	}
+
+	return team, nil
+}
+
+func (s *quizService) SubmitAnswer(ctx context.Context, userID, quizID string, req *model.SubmitAnswerRequest) (_ *model.SubmitAnswerResponse, err error) {
+	ctx, span := tracing.Start(ctx, "quizService.SubmitAnswer",
+		attribute.String("quiz.id", quizID), attribute.String("question.id", req.QuestionID))
+	defer func() { tracing.End(span, err) }()
+
 	userUUID, _ := uuid.Parse(userID)
-	quizUUID, _ := uuid.Parse(quizID)
 	questionUUID, _ := uuid.Parse(req.QuestionID)
 
 	// Get quiz and question
-	quiz, err := s.GetQuiz(quizID)
+	quiz, err := s.GetQuiz(ctx, quizID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Answers are revealed in review once the quiz is over
+	if isFinished(quiz.Status) {
+		return nil, fmt.Errorf("quiz is already completed")
+	}
+	if quiz.StartsAt != nil && time.Now().Before(*quiz.StartsAt) {
+		return nil, fmt.Errorf("quiz has not started yet")
+	}
+
+	var attemptID *uuid.UUID
+	if quiz.Mode == "self_paced" {
+		attempt, err := s.activeAttempt(ctx, quiz, userUUID)
+		if err != nil {
+			return nil, err
+		}
+		attemptID = &attempt.ID
+	}
+
 	var question *model.Question
 	for _, q := range quiz.Questions {
 		if q.ID == questionUUID {
@@ -138,35 +380,50 @@ func (s *quizService) SubmitAnswer(userID, quizID string, req *model.SubmitAnswe
 
 	// Save answer
 	answer := &model.UserAnswer{
-		ID:         uuid.New(),
-		UserID:     userUUID,
-		QuestionID: questionUUID,
-		Answer:     req.Answer,
-		IsCorrect:  isCorrect,
-		AnsweredAt: time.Now(),
+		ID:             uuid.New(),
+		UserID:         userUUID,
+		QuestionID:     questionUUID,
+		AttemptID:      attemptID,
+		Answer:         req.Answer,
+		IsCorrect:      isCorrect,
+		ResponseTimeMs: req.ResponseTimeMs,
+		AnsweredAt:     time.Now(),
 	}
 
-	if err := s.quizRepo.SaveAnswer(answer); err != nil {
-		return nil, err
+	// The answer and the participant's running score commit together, so the
+	// score returned here always includes this answer and no other is lost
+	score, err := s.quizRepo.RecordAnswer(ctx, quiz.ID, answer, points)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save answer: %w", err)
+	}
+	if isCorrect {
+		metrics.AnswersSubmitted.WithLabelValues("correct").Inc()
+	} else {
+		metrics.AnswersSubmitted.WithLabelValues("incorrect").Inc()
 	}
 
-	log.Printf("🗑️ Invalidating cache due to new answer for quiz %s", quizID)
-
-	go func() {
-		if err := s.InvalidateLeaderboardCache(quizID); err != nil {
-			log.Printf("⚠️ Failed to invalidate leaderboard cache: %v", err)
-		}
-
-	}()
+	newScore := score.Score
+	if quiz.Mode == "self_paced" {
+		newScore = applyScoringPolicy(quiz.ScoringPolicy, score.Attempts)
+	}
 
-	// Get updated score
-	newScore, err := s.quizRepo.GetUserScore(userUUID, quizUUID)
-	if err != nil {
-		return nil, err
+	// Drop the cached leaderboard before replying, so a client reading it
+	// right after this answer never sees standings from before it
+	slog.InfoContext(ctx, "Answer recorded", "quiz_id", quizID, "user_id", userID,
+		"question_id", req.QuestionID, "correct", isCorrect, "score", newScore)
+	if err := s.InvalidateLeaderboardCache(ctx, quizID); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate leaderboard cache", "quiz_id", quizID, "error", err)
 	}
 
 	// Update leaderboard and broadcast if there are viewers
-	go s.updateAndBroadcastLeaderboard(quizID)
+	s.background(ctx, func(ctx context.Context) { s.updateAndBroadcastLeaderboard(ctx, quizID) })
+	if quiz.TeamMode != "" && quiz.TeamMode != "none" {
+		s.background(ctx, func(ctx context.Context) { s.updateAndBroadcastTeamLeaderboard(ctx, quizID) })
+	}
+	if quiz.SeriesID != nil {
+		seriesID := quiz.SeriesID.String()
+		s.background(ctx, func(ctx context.Context) { s.seriesService.RefreshSeriesLeaderboard(ctx, seriesID) })
+	}
 
 	return &model.SubmitAnswerResponse{
 		Correct:  isCorrect,
@@ -175,31 +432,104 @@ func (s *quizService) SubmitAnswer(userID, quizID string, req *model.SubmitAnswe
 	}, nil
 }
 
-func (s *quizService) updateAndBroadcastLeaderboard(quizID string) {
+func (s *quizService) updateAndBroadcastLeaderboard(ctx context.Context, quizID string) {
 	if !s.wsService.HasLeaderboardViewers(quizID) {
 		return
 	}
 
-	leaderboard, err := s.GetLeaderboard(quizID)
+	ctx, span := tracing.Start(ctx, "quizService.broadcastLeaderboard", attribute.String("quiz.id", quizID))
+	defer span.End()
+
+	leaderboard, err := s.GetLeaderboard(ctx, quizID)
 	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Failed to load leaderboard for broadcast", "quiz_id", quizID, "error", err)
 		return
 	}
 	// Broadcast to WebSocket viewers
+	slog.DebugContext(ctx, "Broadcasting leaderboard update", "quiz_id", quizID, "entries", len(leaderboard))
+	_, broadcast := tracing.Start(ctx, "websocket.broadcast", attribute.String("hub", quizID))
 	s.wsService.BroadcastLeaderboardUpdate(quizID, leaderboard)
+	broadcast.End()
+}
+
+// updateAndBroadcastTeamLeaderboard drops the cached team leaderboard and
+// pushes a fresh one to live viewers, if any.
+func (s *quizService) updateAndBroadcastTeamLeaderboard(ctx context.Context, quizID string) {
+	key := fmt.Sprintf("team_leaderboard:%s", quizID)
+	if err := s.redisRepo.DeleteKey(ctx, key); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate team leaderboard cache", "quiz_id", quizID, "error", err)
+	}
+
+	if !s.wsService.HasTeamLeaderboardViewers(quizID) {
+		return
+	}
+
+	ctx, span := tracing.Start(ctx, "quizService.broadcastTeamLeaderboard", attribute.String("quiz.id", quizID))
+	defer span.End()
+
+	leaderboard, err := s.GetTeamLeaderboard(ctx, quizID)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Failed to load team leaderboard for broadcast", "quiz_id", quizID, "error", err)
+		return
+	}
+	slog.DebugContext(ctx, "Broadcasting team leaderboard update", "quiz_id", quizID, "teams", len(leaderboard))
+	_, broadcast := tracing.Start(ctx, "websocket.broadcast", attribute.String("hub", "team:"+quizID))
+	s.wsService.BroadcastTeamLeaderboardUpdate(quizID, leaderboard)
+	broadcast.End()
 }
 
-func (s *quizService) GetLeaderboard(quizID string) ([]model.LeaderboardEntry, error) {
+func (s *quizService) GetLeaderboard(ctx context.Context, quizID string) ([]model.LeaderboardEntry, error) {
 	quizUUID, err := uuid.Parse(quizID)
 	if err != nil {
 		return nil, err
 	}
 
-	cachedLeaderboard, err := s.redisRepo.GetLeaderboard(quizID)
-	if err == nil && len(cachedLeaderboard) > 0 {
-		return cachedLeaderboard, nil
+	return fetchCached(ctx, s.leaderboardCache, fmt.Sprintf("leaderboard:%s", quizID),
+		func(ctx context.Context) ([]model.LeaderboardEntry, bool) {
+			cached, err := s.redisRepo.GetLeaderboard(ctx, quizID)
+			return cached, err == nil && len(cached) > 0
+		},
+		func(ctx context.Context) ([]model.LeaderboardEntry, error) {
+			return s.loadLeaderboard(ctx, quizUUID)
+		})
+}
+
+// loadLeaderboard computes a quiz's standings from the database and caches them.
+func (s *quizService) loadLeaderboard(ctx context.Context, quizUUID uuid.UUID) ([]model.LeaderboardEntry, error) {
+	quizID := quizUUID.String()
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Finished quizzes are served from their frozen standings
+	if isFinished(quiz.Status) {
+		results, err := s.quizRepo.GetQuizResults(ctx, quizUUID)
+		if err == nil && len(results) > 0 {
+			var leaderboard []model.LeaderboardEntry
+			participants := make([]model.Participant, 0, len(results))
+			for _, r := range results {
+				leaderboard = append(leaderboard, model.LeaderboardEntry{
+					UserID:   r.UserID,
+					Username: r.Username,
+					Score:    r.Score,
+					Rank:     r.Rank,
+				})
+				participants = append(participants, model.Participant{
+					UserID:   r.UserID,
+					QuizID:   quizUUID,
+					Username: r.Username,
+					Score:    r.Score,
+				})
+			}
+			s.redisRepo.UpdateLeaderboard(ctx, quizID, participants)
+			return leaderboard, nil
+		}
 	}
 
-	participants, err := s.quizRepo.GetParticipants(quizUUID)
+	participants, err := s.scoredParticipants(ctx, quiz)
 	if err != nil {
 		return nil, err
 	}
@@ -215,71 +545,1080 @@ func (s *quizService) GetLeaderboard(quizID string) ([]model.LeaderboardEntry, e
 	}
 
 	// Update Redis cache
-	s.redisRepo.UpdateLeaderboard(quizID, participants)
+	s.redisRepo.UpdateLeaderboard(ctx, quizID, participants)
 
 	return leaderboard, nil
 }
 
-func (s *quizService) GetQuiz(quizID string) (*model.QuizSession, error) {
-	quizUUID, err := uuid.Parse(quizID)
+// userScore is a participant's score in a quiz. Self-paced quizzes combine
+// the scores of each attempt according to the quiz's scoring policy.
+func (s *quizService) userScore(ctx context.Context, quiz *model.QuizSession, userID uuid.UUID) (int, error) {
+	if quiz.Mode != "self_paced" {
+		return s.quizRepo.GetUserScore(ctx, userID, quiz.ID)
+	}
+
+	attempts, err := s.quizRepo.GetUserAttemptScores(ctx, quiz.ID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return applyScoringPolicy(quiz.ScoringPolicy, attempts), nil
+}
+
+// scoredParticipants lists a quiz's participants, highest score first, with
+// scores following the quiz's scoring policy.
+func (s *quizService) scoredParticipants(ctx context.Context, quiz *model.QuizSession) ([]model.Participant, error) {
+	participants, err := s.quizRepo.GetParticipants(ctx, quiz.ID)
+	if err != nil || quiz.Mode != "self_paced" {
+		return participants, err
+	}
+
+	attempts, err := s.quizRepo.GetAttemptScores(ctx, quiz.ID)
 	if err != nil {
 		return nil, err
 	}
-	cachedQuiz, err := s.redisRepo.GetQuizSession(quizID)
-	if err == nil {
-		return cachedQuiz, nil
+	attemptsByUser := make(map[uuid.UUID][]model.AttemptScore)
+	for _, a := range attempts {
+		attemptsByUser[a.UserID] = append(attemptsByUser[a.UserID], a)
+	}
+
+	for i := range participants {
+		participants[i].Score = applyScoringPolicy(quiz.ScoringPolicy, attemptsByUser[participants[i].UserID])
+	}
+	sort.SliceStable(participants, func(i, j int) bool {
+		return participants[i].Score > participants[j].Score
+	})
+
+	return participants, nil
+}
+
+// applyScoringPolicy combines per-attempt scores, ordered by attempt number.
+// Only finished attempts count once there is at least one, so a retry in
+// progress does not drag the score down.
+func applyScoringPolicy(policy string, attempts []model.AttemptScore) int {
+	var counted []model.AttemptScore
+	for _, a := range attempts {
+		if a.Status != "in_progress" {
+			counted = append(counted, a)
+		}
 	}
-	quiz, err := s.quizRepo.GetQuiz(quizUUID)
+	if len(counted) == 0 {
+		counted = attempts
+	}
+	if len(counted) == 0 {
+		return 0
+	}
+
+	switch policy {
+	case "latest":
+		return counted[len(counted)-1].Score
+	case "average":
+		total := 0
+		for _, a := range counted {
+			total += a.Score
+		}
+		return int(math.Round(float64(total) / float64(len(counted))))
+	default:
+		best := counted[0].Score
+		for _, a := range counted[1:] {
+			if a.Score > best {
+				best = a.Score
+			}
+		}
+		return best
+	}
+}
+
+// GetQuiz returns the quiz with its questions, including correct answers.
+// The result may be shared with concurrent callers, so it must not be
+// modified.
+func (s *quizService) GetQuiz(ctx context.Context, quizID string) (*model.QuizSession, error) {
+	quizUUID, err := uuid.Parse(quizID)
 	if err != nil {
 		return nil, err
 	}
-	s.redisRepo.SetQuizSession(quizID, quiz)
-	return quiz, nil
+
+	return fetchCached(ctx, s.quizCache, fmt.Sprintf("quiz:%s", quizID),
+		func(ctx context.Context) (*model.QuizSession, bool) {
+			cached, err := s.redisRepo.GetQuizSession(ctx, quizID)
+			return cached, err == nil
+		},
+		func(ctx context.Context) (*model.QuizSession, error) {
+			quiz, err := s.quizRepo.GetQuiz(ctx, quizUUID)
+			if err != nil {
+				return nil, err
+			}
+			s.redisRepo.SetQuizSession(ctx, quizID, quiz)
+			return quiz, nil
+		})
 }
 
-// ================================================================
-// 4. CACHE MANAGEMENT METHODS
-// ================================================================
+// statusTransitions lists the status a host may move a quiz to from each
+// status. A finished quiz has revealed its answers, so it never reopens.
+var statusTransitions = map[string]string{
+	"waiting": "active",
+	"active":  "completed",
+}
 
-func (s *quizService) InvalidateQuizCache(quizID string) error {
-	key := fmt.Sprintf("quiz:%s", quizID)
-	if err := s.redisRepo.DeleteKey(key); err != nil {
-		return fmt.Errorf("failed to invalidate quiz cache: %w", err)
+// UpdateQuizStatus moves a quiz one step along waiting, active, completed.
+func (s *quizService) UpdateQuizStatus(ctx context.Context, quizID string, status string) (*model.QuizSession, error) {
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiz ID")
 	}
-	log.Printf("🗑️ Invalidated quiz cache for %s", quizID)
-	return nil
+
+	quiz, err := s.quizRepo.GetQuiz(ctx, quizUUID)
+	if err != nil {
+		return nil, fmt.Errorf("quiz not found")
+	}
+	if statusTransitions[quiz.Status] != status {
+		return nil, fmt.Errorf("cannot change quiz status from %s to %s", quiz.Status, status)
+	}
+
+	return s.setQuizStatus(ctx, quizUUID, status)
 }
 
-func (s *quizService) InvalidateLeaderboardCache(quizID string) error {
-	key := fmt.Sprintf("leaderboard:%s", quizID)
-	if err := s.redisRepo.DeleteKey(key); err != nil {
-		return fmt.Errorf("failed to invalidate leaderboard cache: %w", err)
+// setQuizStatus stores status without checking the transition, freezing the
+// results of a quiz that has finished.
+func (s *quizService) setQuizStatus(ctx context.Context, quizUUID uuid.UUID, status string) (*model.QuizSession, error) {
+	quizID := quizUUID.String()
+	if err := s.quizRepo.UpdateQuizStatus(ctx, quizUUID, status); err != nil {
+		return nil, fmt.Errorf("quiz not found")
 	}
-	log.Printf("🗑️ Invalidated leaderboard cache for %s", quizID)
-	return nil
+
+	if isFinished(status) {
+		if err := s.freezeResults(ctx, quizUUID); err != nil {
+			return nil, fmt.Errorf("failed to save final standings: %w", err)
+		}
+	}
+
+	if err := s.InvalidateQuizCache(ctx, quizID); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate quiz cache", "quiz_id", quizID, "error", err)
+	}
+
+	return s.GetQuiz(ctx, quizID)
 }
 
-// ================================================================
-// 5. CACHE WARMUP - Pre-load popular data
-// ================================================================
+// freezeResults snapshots the current standings of a quiz into quiz_results,
+// using each participant's last answer as their completion time.
+func (s *quizService) freezeResults(ctx context.Context, quizID uuid.UUID) error {
+	quiz, err := s.quizRepo.GetQuiz(ctx, quizID)
+	if err != nil {
+		return err
+	}
 
-func (s *quizService) WarmupCache(quizID string) error {
-	log.Printf("🔥 Starting cache warmup for quiz %s", quizID)
+	participants, err := s.scoredParticipants(ctx, quiz)
+	if err != nil {
+		return err
+	}
 
-	startTime := time.Now()
+	answers, err := s.quizRepo.GetQuizAnswers(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	completedAt := make(map[uuid.UUID]time.Time)
+	for _, a := range answers {
+		if a.AnsweredAt.After(completedAt[a.UserID]) {
+			completedAt[a.UserID] = a.AnsweredAt
+		}
+	}
 
-	// Warm up quiz data
-	if _, err := s.GetQuiz(quizID); err != nil {
-		return fmt.Errorf("failed to warm up quiz cache: %w", err)
+	now := time.Now()
+	results := make([]model.QuizResult, 0, len(participants))
+	for i, p := range participants {
+		results = append(results, model.QuizResult{
+			ID:            uuid.New(),
+			QuizSessionID: quizID,
+			UserID:        p.UserID,
+			Username:      p.Username,
+			Rank:          i + 1,
+			Score:         p.Score,
+			CompletedAt:   completedAt[p.UserID],
+			CreatedAt:     now,
+		})
 	}
 
-	// Warm up leaderboard
-	if _, err := s.GetLeaderboard(quizID); err != nil {
-		return fmt.Errorf("failed to warm up leaderboard cache: %w", err)
+	if err := s.quizRepo.SaveQuizResults(ctx, quizID, results); err != nil {
+		return err
 	}
 
-	elapsed := time.Since(startTime)
-	log.Printf("🔥 Cache warmup completed for quiz %s (took %v)", quizID, elapsed)
+	slog.InfoContext(ctx, "Saved final standings", "quiz_id", quizID, "participants", len(results))
 
+	if err := s.InvalidateLeaderboardCache(ctx, quizID.String()); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate leaderboard cache", "quiz_id", quizID, "error", err)
+	}
 	return nil
 }
+
+func (s *quizService) GetTeams(ctx context.Context, quizID string) ([]model.Team, error) {
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiz ID")
+	}
+	return s.quizRepo.GetTeams(ctx, quizUUID)
+}
+
+func (s *quizService) GetTeamLeaderboard(ctx context.Context, quizID string) ([]model.TeamLeaderboardEntry, error) {
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiz ID")
+	}
+
+	return fetchCached(ctx, s.teamLeaderboardCache, fmt.Sprintf("team_leaderboard:%s", quizID),
+		func(ctx context.Context) ([]model.TeamLeaderboardEntry, bool) {
+			cached, err := s.redisRepo.GetTeamLeaderboard(ctx, quizID)
+			return cached, err == nil && len(cached) > 0
+		},
+		func(ctx context.Context) ([]model.TeamLeaderboardEntry, error) {
+			return s.loadTeamLeaderboard(ctx, quizUUID)
+		})
+}
+
+// loadTeamLeaderboard computes a quiz's team standings from the database and
+// caches them.
+func (s *quizService) loadTeamLeaderboard(ctx context.Context, quizUUID uuid.UUID) ([]model.TeamLeaderboardEntry, error) {
+	quizID := quizUUID.String()
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("quiz not found")
+	}
+
+	teams, err := s.quizRepo.GetTeams(ctx, quizUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants, err := s.scoredParticipants(ctx, quiz)
+	if err != nil {
+		return nil, err
+	}
+	scoreByUser := make(map[uuid.UUID]int, len(participants))
+	for _, p := range participants {
+		scoreByUser[p.UserID] = p.Score
+	}
+
+	leaderboard := make([]model.TeamLeaderboardEntry, 0, len(teams))
+	for _, team := range teams {
+		entry := model.TeamLeaderboardEntry{
+			TeamID:   team.ID,
+			TeamName: team.Name,
+			Members:  len(team.Members),
+		}
+		for _, member := range team.Members {
+			entry.Score += scoreByUser[member.UserID]
+		}
+		if quiz.TeamScoring == "average" && entry.Members > 0 {
+			entry.Score = int(math.Round(float64(entry.Score) / float64(entry.Members)))
+		}
+		leaderboard = append(leaderboard, entry)
+	}
+
+	sort.SliceStable(leaderboard, func(i, j int) bool {
+		return leaderboard[i].Score > leaderboard[j].Score
+	})
+	for i := range leaderboard {
+		leaderboard[i].Rank = i + 1
+	}
+
+	// Update Redis cache
+	s.redisRepo.UpdateTeamLeaderboard(ctx, quizID, leaderboard)
+
+	return leaderboard, nil
+}
+
+func (s *quizService) GetQuizResults(ctx context.Context, quizID string) ([]model.QuizResult, error) {
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiz ID")
+	}
+	return s.quizRepo.GetQuizResults(ctx, quizUUID)
+}
+
+func (s *quizService) GetUserHistory(ctx context.Context, userID string) ([]model.UserQuizResult, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	return s.quizRepo.GetUserResults(ctx, userUUID)
+}
+
+func (s *quizService) GetRecentWinners(ctx context.Context, limit int) ([]model.UserQuizResult, error) {
+	return s.quizRepo.GetRecentWinners(ctx, limit)
+}
+
+func (s *quizService) GetParticipantStats(ctx context.Context, userID, quizID string) (*model.ParticipantStats, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("quiz not found")
+	}
+
+	user, err := s.quizRepo.GetUser(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	score, err := s.userScore(ctx, quiz, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	answers, err := s.userAnswers(ctx, quiz, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the first answer submitted for each question
+	answerByQuestion := make(map[uuid.UUID]model.UserAnswer)
+	for _, a := range answers {
+		if _, exists := answerByQuestion[a.QuestionID]; !exists {
+			answerByQuestion[a.QuestionID] = a
+		}
+	}
+
+	stats := &model.ParticipantStats{
+		UserID:    user.ID,
+		QuizID:    quiz.ID,
+		Username:  user.Username,
+		Score:     score,
+		Questions: make([]model.QuestionResult, 0, len(quiz.Questions)),
+	}
+
+	timedAnswers, totalResponseTime := 0, 0
+	for _, q := range quiz.Questions {
+		result := model.QuestionResult{
+			QuestionID: q.ID,
+			Order:      q.Order,
+		}
+
+		answer, answered := answerByQuestion[q.ID]
+		if answered {
+			result.Answered = true
+			result.Answer = answer.Answer
+			result.ResponseTimeMs = answer.ResponseTimeMs
+			stats.AnsweredQuestions++
+			if answer.ResponseTimeMs > 0 {
+				timedAnswers++
+				totalResponseTime += answer.ResponseTimeMs
+			}
+		} else {
+			stats.UnansweredQuestions++
+		}
+
+		if isAnswerRevealed(quiz, answered) {
+			correct := answered && answer.IsCorrect
+			result.Correct = &correct
+		}
+
+		stats.Questions = append(stats.Questions, result)
+	}
+
+	sort.Slice(stats.Questions, func(i, j int) bool {
+		return stats.Questions[i].Order < stats.Questions[j].Order
+	})
+
+	if timedAnswers > 0 {
+		stats.AverageResponseTimeMs = float64(totalResponseTime) / float64(timedAnswers)
+	}
+
+	leaderboard, err := s.GetLeaderboard(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range leaderboard {
+		if entry.UserID == user.ID {
+			stats.Rank = entry.Rank
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *quizService) GetQuizReview(ctx context.Context, userID, quizID string) (*model.QuizReview, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("quiz not found")
+	}
+
+	switch quiz.ReviewVisibility {
+	case "never":
+		return nil, ErrReviewNotAvailable
+	case "after_answer":
+		// Each question is revealed as soon as the participant has answered it
+	default:
+		if !isFinished(quiz.Status) {
+			return nil, ErrReviewNotAvailable
+		}
+	}
+
+	answers, err := s.userAnswers(ctx, quiz, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	answerByQuestion := make(map[uuid.UUID]model.UserAnswer)
+	for _, a := range answers {
+		if _, exists := answerByQuestion[a.QuestionID]; !exists {
+			answerByQuestion[a.QuestionID] = a
+		}
+	}
+
+	counts, err := s.quizRepo.GetAnswerCounts(ctx, quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	countsByQuestion := make(map[uuid.UUID]map[string]int)
+	for _, c := range counts {
+		if countsByQuestion[c.QuestionID] == nil {
+			countsByQuestion[c.QuestionID] = make(map[string]int)
+		}
+		countsByQuestion[c.QuestionID][c.Answer] += c.Count
+	}
+
+	review := &model.QuizReview{
+		QuizID:           quiz.ID,
+		Title:            quiz.Title,
+		Status:           quiz.Status,
+		ReviewVisibility: quiz.ReviewVisibility,
+		Questions:        make([]model.QuestionReview, 0, len(quiz.Questions)),
+	}
+
+	for _, q := range quiz.Questions {
+		qr := model.QuestionReview{
+			QuestionID:   q.ID,
+			Order:        q.Order,
+			QuestionText: q.QuestionText,
+			Options:      q.Options,
+			Points:       q.Points,
+		}
+
+		answer, answered := answerByQuestion[q.ID]
+		if answered {
+			qr.YourAnswer = answer.Answer
+		}
+
+		if isAnswerRevealed(quiz, answered) {
+			qr.Revealed = true
+			qr.CorrectAnswer = q.CorrectAnswer
+			correct := answered && answer.IsCorrect
+			qr.Correct = &correct
+
+			optionCounts := countsByQuestion[q.ID]
+			for _, count := range optionCounts {
+				qr.TotalAnswers += count
+			}
+			for _, option := range q.Options {
+				stat := model.OptionStat{Option: option, Count: optionCounts[option]}
+				if qr.TotalAnswers > 0 {
+					stat.Percentage = float64(stat.Count) * 100 / float64(qr.TotalAnswers)
+				}
+				qr.OptionStats = append(qr.OptionStats, stat)
+			}
+		}
+
+		review.Questions = append(review.Questions, qr)
+	}
+
+	sort.Slice(review.Questions, func(i, j int) bool {
+		return review.Questions[i].Order < review.Questions[j].Order
+	})
+
+	return review, nil
+}
+
+// userAnswers returns a participant's answers in a quiz. For self-paced
+// quizzes only the answers of their latest attempt are returned.
+func (s *quizService) userAnswers(ctx context.Context, quiz *model.QuizSession, userID uuid.UUID) ([]model.UserAnswer, error) {
+	answers, err := s.quizRepo.GetUserAnswers(ctx, userID, quiz.ID)
+	if err != nil || quiz.Mode != "self_paced" {
+		return answers, err
+	}
+
+	// Without an attempt there is nothing to show yet; any other failure is
+	// reported rather than passed off as no answers
+	attempt, err := s.quizRepo.GetAttempt(ctx, quiz.ID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var latest []model.UserAnswer
+	for _, a := range answers {
+		if a.AttemptID != nil && *a.AttemptID == attempt.ID {
+			latest = append(latest, a)
+		}
+	}
+	return latest, nil
+}
+
+// isAnswerRevealed reports whether a participant may see the outcome of a
+// question: once they have answered it, or once the whole quiz is over.
+// Participants move through questions at their own pace, so a question never
+// closes for everyone before the quiz does.
+func isAnswerRevealed(quiz *model.QuizSession, answered bool) bool {
+	return answered || isFinished(quiz.Status)
+}
+
+// isFinished reports whether a quiz is over: completed, or completed and
+// since archived by the retention policy.
+func isFinished(status string) bool {
+	return status == "completed" || status == "archived"
+}
+
+// background runs fn in its own goroutine, tracked so Drain can wait for it.
+// fn outlives the request, so it gets ctx's values without its cancellation.
+func (s *quizService) background(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	s.tasks.Add(1)
+	go func() {
+		defer s.tasks.Done()
+		fn(ctx)
+	}()
+}
+
+func (s *quizService) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.tasks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background tasks still running: %w", ctx.Err())
+	}
+}
+
+// ================================================================
+// 3. SELF-PACED ATTEMPTS
+// ================================================================
+
+func (s *quizService) StartAttempt(ctx context.Context, userID, quizID string) (*model.QuizAttempt, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("quiz not found")
+	}
+	if quiz.Mode != "self_paced" {
+		return nil, fmt.Errorf("quiz is not self-paced")
+	}
+
+	now := time.Now()
+	if isFinished(quiz.Status) {
+		return nil, fmt.Errorf("quiz is already completed")
+	}
+	if quiz.StartsAt != nil && now.Before(*quiz.StartsAt) {
+		return nil, fmt.Errorf("quiz has not started yet")
+	}
+	if now.After(quiz.ExpiresAt) {
+		return nil, fmt.Errorf("quiz has expired")
+	}
+
+	if _, err := s.quizRepo.GetUser(ctx, userUUID); err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	// Starting again resumes the attempt already in progress
+	number := 1
+	if latest, err := s.quizRepo.GetAttempt(ctx, quiz.ID, userUUID); err == nil {
+		if err := s.expireAttempt(ctx, latest, now); err != nil {
+			return nil, err
+		}
+		if latest.Status == "in_progress" {
+			return withRemainingTime(latest, now), nil
+		}
+		if latest.Number >= max(quiz.MaxAttempts, 1) {
+			return nil, fmt.Errorf("no attempts left")
+		}
+		number = latest.Number + 1
+	}
+
+	// The personal budget never runs past the quiz window
+	deadline := quiz.ExpiresAt
+	if quiz.TimeLimitSeconds > 0 {
+		if personal := now.Add(time.Duration(quiz.TimeLimitSeconds) * time.Second); personal.Before(deadline) {
+			deadline = personal
+		}
+	}
+
+	attempt := &model.QuizAttempt{
+		ID:            uuid.New(),
+		QuizSessionID: quiz.ID,
+		UserID:        userUUID,
+		Number:        number,
+		Status:        "in_progress",
+		StartedAt:     now,
+		Deadline:      deadline,
+	}
+	if err := s.quizRepo.CreateAttempt(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	// Once the budget runs out the attempt is timed out by the cleanup
+	// worker's sweep, or sooner by expireAttempt when it is next read
+
+	slog.InfoContext(ctx, "Attempt started", "quiz_id", quizID, "user_id", userID, "attempt", number, "deadline", deadline.Format(time.RFC3339))
+
+	return withRemainingTime(attempt, now), nil
+}
+
+func (s *quizService) GetAttempt(ctx context.Context, userID, quizID string) (*model.QuizAttempt, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiz ID")
+	}
+
+	attempt, err := s.quizRepo.GetAttempt(ctx, quizUUID, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("attempt not found")
+	}
+
+	now := time.Now()
+	if err := s.expireAttempt(ctx, attempt, now); err != nil {
+		return nil, err
+	}
+
+	return withRemainingTime(attempt, now), nil
+}
+
+func (s *quizService) SubmitAttempt(ctx context.Context, userID, quizID string) (*model.QuizAttempt, error) {
+	attempt, err := s.GetAttempt(ctx, userID, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch attempt.Status {
+	case "in_progress":
+		now := time.Now()
+		attempt.Status = "submitted"
+		attempt.EndedAt = &now
+		attempt.RemainingSeconds = 0
+		if err := s.quizRepo.UpdateAttempt(ctx, attempt); err != nil {
+			return nil, err
+		}
+		return attempt, nil
+	case "timed_out":
+		// Already auto-submitted when time ran out
+		return attempt, nil
+	default:
+		return nil, fmt.Errorf("attempt already finished")
+	}
+}
+
+// ListAttempts returns every attempt a participant made, each with its own
+// score.
+func (s *quizService) ListAttempts(ctx context.Context, userID, quizID string) ([]model.QuizAttempt, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiz ID")
+	}
+
+	attempts, err := s.quizRepo.ListAttempts(ctx, quizUUID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only this participant's overdue attempt is timed out here; the cleanup
+	// worker sweeps everyone else's
+	now := time.Now()
+	for i := range attempts {
+		if err := s.expireAttempt(ctx, &attempts[i], now); err != nil {
+			return nil, err
+		}
+	}
+
+	scores, err := s.quizRepo.GetUserAttemptScores(ctx, quizUUID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	scoreByAttempt := make(map[uuid.UUID]int, len(scores))
+	for _, sc := range scores {
+		scoreByAttempt[sc.AttemptID] = sc.Score
+	}
+
+	for i := range attempts {
+		attempts[i].Score = scoreByAttempt[attempts[i].ID]
+		withRemainingTime(&attempts[i], now)
+	}
+
+	return attempts, nil
+}
+
+// ExpireQuizzes completes every open quiz past its ExpiresAt, disconnects its
+// viewers and purges its cached data.
+func (s *quizService) ExpireQuizzes(ctx context.Context) (int, error) {
+	quizzes, err := s.quizRepo.GetExpiredQuizzes(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, quiz := range quizzes {
+		quizID := quiz.ID.String()
+		done, err := s.expireQuiz(ctx, quiz.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to expire quiz", "quiz_id", quizID, "error", err)
+			continue
+		}
+		if !done {
+			continue
+		}
+
+		s.wsService.CloseQuizHubs(quizID, "quiz expired")
+
+		if err := s.PurgeQuizCache(ctx, quizID); err != nil {
+			slog.WarnContext(ctx, "Failed to purge quiz cache", "quiz_id", quizID, "error", err)
+		}
+
+		slog.InfoContext(ctx, "Quiz expired", "quiz_id", quizID)
+		expired++
+	}
+
+	return expired, nil
+}
+
+// expireQuiz completes a quiz under a per-quiz lock, so that only one
+// instance freezes its results. It reports false when another instance holds
+// the lock or already finished the quiz.
+func (s *quizService) expireQuiz(ctx context.Context, quizID uuid.UUID) (bool, error) {
+	lockKey := fmt.Sprintf("lock:quiz_expire:%s", quizID)
+	token, acquired, err := s.redisRepo.AcquireLock(ctx, lockKey, expireLockTTL)
+	if err != nil || !acquired {
+		return false, err
+	}
+	defer s.redisRepo.ReleaseLock(ctx, lockKey, token)
+
+	// Another instance may have expired it before this one took the lock
+	quiz, err := s.quizRepo.GetQuiz(ctx, quizID)
+	if err != nil {
+		return false, err
+	}
+	if isFinished(quiz.Status) {
+		return false, nil
+	}
+
+	// A quiz that expires before it was ever started completes as well
+	if _, err := s.setQuizStatus(ctx, quizID, "completed"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FinalizeExpiredAttempts auto-submits every attempt whose time has run out.
+func (s *quizService) FinalizeExpiredAttempts(ctx context.Context) (int64, error) {
+	finished, err := s.quizRepo.FinishExpiredAttempts(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if finished > 0 {
+		slog.InfoContext(ctx, "Auto-submitted expired attempts", "count", finished)
+	}
+	return finished, nil
+}
+
+// activeAttempt returns the participant's running attempt, or an error
+// explaining why they cannot answer.
+func (s *quizService) activeAttempt(ctx context.Context, quiz *model.QuizSession, userID uuid.UUID) (*model.QuizAttempt, error) {
+	attempt, err := s.quizRepo.GetAttempt(ctx, quiz.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("start the quiz before answering")
+	}
+
+	if err := s.expireAttempt(ctx, attempt, time.Now()); err != nil {
+		return nil, err
+	}
+
+	switch attempt.Status {
+	case "in_progress":
+		return attempt, nil
+	case "timed_out":
+		return nil, fmt.Errorf("time is up for this attempt")
+	default:
+		return nil, fmt.Errorf("attempt already submitted")
+	}
+}
+
+// expireAttempt times out an in-progress attempt whose deadline has passed.
+func (s *quizService) expireAttempt(ctx context.Context, attempt *model.QuizAttempt, now time.Time) error {
+	if attempt.Status != "in_progress" || !now.After(attempt.Deadline) {
+		return nil
+	}
+
+	deadline := attempt.Deadline
+	attempt.Status = "timed_out"
+	attempt.EndedAt = &deadline
+	return s.quizRepo.UpdateAttempt(ctx, attempt)
+}
+
+func withRemainingTime(attempt *model.QuizAttempt, now time.Time) *model.QuizAttempt {
+	attempt.RemainingSeconds = 0
+	if attempt.Status == "in_progress" && attempt.Deadline.After(now) {
+		attempt.RemainingSeconds = int(math.Ceil(attempt.Deadline.Sub(now).Seconds()))
+	}
+	return attempt
+}
+
+// ================================================================
+// 4. RECONNECT AND RESUME
+// ================================================================
+
+// issueParticipantToken creates a resume token that lives as long as the quiz.
+func (s *quizService) issueParticipantToken(ctx context.Context, quiz *model.QuizSession, user *model.User) (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	ttl := time.Until(quiz.ExpiresAt)
+	if ttl < time.Minute {
+		ttl = time.Minute
+	}
+	if err := s.redisRepo.SaveParticipantToken(ctx, token, quiz.ID.String(), user.ID.String(), ttl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResumeParticipant rebuilds a participant's view of the quiz from their
+// token: the next unanswered question, time left, answers so far and score.
+func (s *quizService) ResumeParticipant(ctx context.Context, quizID, token string) (*model.ResumeState, error) {
+	tokenQuizID, userID, err := s.redisRepo.GetParticipantToken(ctx, token)
+	if err != nil || tokenQuizID != quizID {
+		return nil, ErrInvalidParticipantToken
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrInvalidParticipantToken
+	}
+
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("quiz not found")
+	}
+
+	user, err := s.quizRepo.GetUser(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	state := &model.ResumeState{
+		QuizID:         quiz.ID,
+		UserID:         user.ID,
+		Username:       user.Username,
+		Status:         quiz.Status,
+		Mode:           quiz.Mode,
+		TotalQuestions: len(quiz.Questions),
+		Answers:        []model.SubmittedAnswer{},
+		StartsAt:       quiz.StartsAt,
+	}
+
+	// Only offer a next question while the participant can still answer
+	answering := quiz.Status == "active"
+	now := time.Now()
+	if quiz.Mode == "self_paced" {
+		answering = false
+		if attempt, err := s.GetAttempt(ctx, userID, quizID); err == nil {
+			state.Attempt = attempt
+			state.RemainingSeconds = attempt.RemainingSeconds
+			answering = attempt.Status == "in_progress"
+		}
+	} else if answering && quiz.ExpiresAt.After(now) {
+		state.RemainingSeconds = int(math.Ceil(quiz.ExpiresAt.Sub(now).Seconds()))
+	}
+
+	if state.Score, err = s.userScore(ctx, quiz, userUUID); err != nil {
+		return nil, err
+	}
+
+	answers, err := s.userAnswers(ctx, quiz, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	answered := make(map[uuid.UUID]bool, len(answers))
+	for _, a := range answers {
+		if answered[a.QuestionID] {
+			continue
+		}
+		answered[a.QuestionID] = true
+		state.Answers = append(state.Answers, model.SubmittedAnswer{
+			QuestionID: a.QuestionID,
+			Answer:     a.Answer,
+			AnsweredAt: a.AnsweredAt,
+		})
+	}
+
+	if answering {
+		questions := append([]model.Question(nil), quiz.Questions...)
+		sort.Slice(questions, func(i, j int) bool {
+			return questions[i].Order < questions[j].Order
+		})
+		for _, q := range questions {
+			if !answered[q.ID] {
+				state.CurrentQuestion = &model.ResumeQuestion{
+					ID:           q.ID,
+					Order:        q.Order,
+					QuestionText: q.QuestionText,
+					Options:      q.Options,
+					Points:       q.Points,
+				}
+				break
+			}
+		}
+	}
+
+	return state, nil
+}
+
+// ================================================================
+// 5. CACHE MANAGEMENT METHODS
+// ================================================================
+
+func (s *quizService) InvalidateQuizCache(ctx context.Context, quizID string) error {
+	key := fmt.Sprintf("quiz:%s", quizID)
+	if err := s.redisRepo.DeleteKey(ctx, key); err != nil {
+		return fmt.Errorf("failed to invalidate quiz cache: %w", err)
+	}
+	slog.DebugContext(ctx, "Invalidated quiz cache", "quiz_id", quizID)
+	return nil
+}
+
+func (s *quizService) InvalidateLeaderboardCache(ctx context.Context, quizID string) error {
+	key := fmt.Sprintf("leaderboard:%s", quizID)
+	if err := s.redisRepo.DeleteKey(ctx, key); err != nil {
+		return fmt.Errorf("failed to invalidate leaderboard cache: %w", err)
+	}
+	slog.DebugContext(ctx, "Invalidated leaderboard cache", "quiz_id", quizID)
+	return nil
+}
+
+// quizCacheKeys lists every Redis key held for a quiz, including the lobby
+// roster and heartbeat set so an expired quiz leaves nothing behind.
+func quizCacheKeys(quizID string) []string {
+	return []string{
+		fmt.Sprintf("quiz:%s", quizID),
+		fmt.Sprintf("leaderboard:%s", quizID),
+		fmt.Sprintf("team_leaderboard:%s", quizID),
+		fmt.Sprintf("lobby:%s", quizID),
+		fmt.Sprintf("presence:%s", quizID),
+	}
+}
+
+// PurgeQuizCache removes every Redis key held for a quiz.
+func (s *quizService) PurgeQuizCache(ctx context.Context, quizID string) error {
+	for _, key := range quizCacheKeys(quizID) {
+		if err := s.redisRepo.DeleteKey(ctx, key); err != nil {
+			return fmt.Errorf("failed to purge quiz cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// InspectQuizCache reports which of a quiz's cache keys exist and their TTLs.
+func (s *quizService) InspectQuizCache(ctx context.Context, quizID string) ([]model.CacheEntry, error) {
+	if _, err := uuid.Parse(quizID); err != nil {
+		return nil, fmt.Errorf("invalid quiz ID")
+	}
+
+	keys := quizCacheKeys(quizID)
+	entries := make([]model.CacheEntry, 0, len(keys))
+	for _, key := range keys {
+		exists, err := s.redisRepo.KeyExists(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect cache: %w", err)
+		}
+		entry := model.CacheEntry{Key: key, Exists: exists}
+		if exists {
+			ttl, err := s.redisRepo.GetTTL(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to inspect cache: %w", err)
+			}
+			seconds := int64(ttl / time.Second)
+			if ttl < 0 {
+				seconds = -1
+			}
+			entry.TTLSeconds = &seconds
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ================================================================
+// 6. CACHE WARMUP - Pre-load popular data
+// ================================================================
+
+func (s *quizService) WarmupCache(ctx context.Context, quizID string) error {
+	slog.DebugContext(ctx, "Starting cache warmup", "quiz_id", quizID)
+
+	startTime := time.Now()
+
+	// Warm up quiz data
+	if _, err := s.GetQuiz(ctx, quizID); err != nil {
+		return fmt.Errorf("failed to warm up quiz cache: %w", err)
+	}
+
+	// Warm up leaderboard
+	if _, err := s.GetLeaderboard(ctx, quizID); err != nil {
+		return fmt.Errorf("failed to warm up leaderboard cache: %w", err)
+	}
+
+	elapsed := time.Since(startTime)
+	slog.InfoContext(ctx, "Cache warmup completed", "quiz_id", quizID, "took", elapsed)
+
+	return nil
+}
+
+// CacheStats reports hit and miss counts for the quiz and leaderboard caches.
+func (s *quizService) CacheStats() []model.CacheStats {
+	return []model.CacheStats{
+		s.quizCache.stats(),
+		s.leaderboardCache.stats(),
+		s.teamLeaderboardCache.stats(),
+	}
+}
+
+// WarmupActiveQuizzes warms every active quiz and returns how many succeeded.
+// A quiz that fails is logged and skipped; the error joins all failures.
+func (s *quizService) WarmupActiveQuizzes(ctx context.Context) (int, error) {
+	ids, err := s.quizRepo.GetQuizIDsByStatus(ctx, "active")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list active quizzes: %w", err)
+	}
+
+	warmed := 0
+	var errs []error
+	for _, id := range ids {
+		if err := s.WarmupCache(ctx, id.String()); err != nil {
+			slog.WarnContext(ctx, "Cache warmup failed", "quiz_id", id, "error", err)
+			errs = append(errs, fmt.Errorf("quiz %s: %w", id, err))
+			continue
+		}
+		warmed++
+	}
+	return warmed, errors.Join(errs...)
+}
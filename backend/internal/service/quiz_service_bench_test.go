@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"quiz-app/internal/config"
+	"quiz-app/internal/model"
+	"quiz-app/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// benchRedisAddrEnv names a Redis server to benchmark against in addition to
+// the in-memory store, e.g. QUIZ_TEST_REDIS_ADDR=localhost:6379.
+const benchRedisAddrEnv = "QUIZ_TEST_REDIS_ADDR"
+
+// localQuizCache mirrors the default redis.local_quiz_cache_* settings.
+var localQuizCache = config.Redis{LocalQuizCacheSize: 1000, LocalQuizCacheTTL: 30 * time.Second}
+
+type benchRedisBackend struct {
+	name string
+	new  func() repository.RedisRepository
+}
+
+// benchRedisBackends returns the quiz caches to benchmark, each with and
+// without the in-process LRU in front.
+func benchRedisBackends(b *testing.B) []benchRedisBackend {
+	// Only used to publish invalidations; the few sent while setting up a
+	// quiz fail against this address and are merely logged
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	b.Cleanup(func() { client.Close() })
+
+	backends := []benchRedisBackend{
+		{"memory", func() repository.RedisRepository {
+			return repository.NewMemoryRedisRepository(config.Redis{})
+		}},
+		{"memory+lru", func() repository.RedisRepository {
+			return repository.NewLocalCacheRepository(repository.NewMemoryRedisRepository(config.Redis{}), client, localQuizCache)
+		}},
+	}
+
+	addr := os.Getenv(benchRedisAddrEnv)
+	if addr == "" {
+		return backends
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	b.Cleanup(func() { rdb.Close() })
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		b.Fatalf("ping %s: %v", addr, err)
+	}
+	return append(backends,
+		benchRedisBackend{"redis", func() repository.RedisRepository {
+			return repository.NewRedisRepository(rdb, time.Second, config.Redis{})
+		}},
+		benchRedisBackend{"redis+lru", func() repository.RedisRepository {
+			return repository.NewLocalCacheRepository(repository.NewRedisRepository(rdb, time.Second, config.Redis{}), rdb, localQuizCache)
+		}},
+	)
+}
+
+func benchQuizRequest() *model.CreateQuizRequest {
+	req := &model.CreateQuizRequest{Title: "Benchmark"}
+	for i := 0; i < 20; i++ {
+		req.Questions = append(req.Questions, model.QuestionRequest{
+			QuestionText:  fmt.Sprintf("Question %d", i),
+			Options:       []string{"a", "b", "c", "d"},
+			CorrectAnswer: "b",
+			Points:        10,
+		})
+	}
+	return req
+}
+
+func BenchmarkGetQuiz(b *testing.B) {
+	ctx := context.Background()
+	for _, backend := range benchRedisBackends(b) {
+		b.Run(backend.name, func(b *testing.B) {
+			s := newTestServicesOver(b, repository.NewMemoryQuizRepository(), backend.new())
+			quiz := startQuiz(b, ctx, s.quiz, benchQuizRequest())
+			quizID := quiz.ID.String()
+			if _, err := s.quiz.GetQuiz(ctx, quizID); err != nil {
+				b.Fatalf("warm cache: %v", err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.quiz.GetQuiz(ctx, quizID); err != nil {
+					b.Fatalf("get quiz: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSubmitAnswer(b *testing.B) {
+	ctx := context.Background()
+	for _, backend := range benchRedisBackends(b) {
+		b.Run(backend.name, func(b *testing.B) {
+			s := newTestServicesOver(b, repository.NewMemoryQuizRepository(), backend.new())
+			quiz := startQuiz(b, ctx, s.quiz, benchQuizRequest())
+			quizID := quiz.ID.String()
+
+			// Each iteration is a different participant's first answer
+			userIDs := make([]string, b.N)
+			for i := range userIDs {
+				userIDs[i] = joinQuiz(b, ctx, s.quiz, quizID, fmt.Sprintf("player%d", i))
+			}
+			req := &model.SubmitAnswerRequest{QuestionID: quiz.Questions[0].ID.String(), Answer: "b"}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.quiz.SubmitAnswer(ctx, userIDs[i], quizID, req); err != nil {
+					b.Fatalf("submit answer: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"quiz-app/internal/config"
+	"quiz-app/internal/migration"
+	"quiz-app/internal/model"
+	"quiz-app/internal/repository"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newSQLiteQuizRepository returns a quiz repository over a migrated SQLite
+// database in a temporary directory, opened as the server opens it.
+func newSQLiteQuizRepository(t testing.TB) repository.QuizRepository {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quiz.db")
+	db, err := gorm.Open(sqlite.Open(path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	migrator, err := migration.New(db, "sqlite")
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return repository.NewQuizRepository(db, 5*time.Second)
+}
+
+func TestConcurrentAnswersCountOncePerQuestion(t *testing.T) {
+	backends := map[string]func(t testing.TB) repository.QuizRepository{
+		"memory": func(testing.TB) repository.QuizRepository { return repository.NewMemoryQuizRepository() },
+		"sqlite": newSQLiteQuizRepository,
+	}
+	for name, newQuizRepo := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestServicesOver(t, newQuizRepo(t), repository.NewMemoryRedisRepository(config.Redis{}))
+
+			// Distinct powers of two make every partial sum unique
+			req := &model.CreateQuizRequest{Title: "Race"}
+			for i := 0; i < 6; i++ {
+				req.Questions = append(req.Questions, model.QuestionRequest{
+					QuestionText:  "Q",
+					Options:       []string{"a", "b"},
+					CorrectAnswer: "a",
+					Points:        1 << i,
+				})
+			}
+			quiz := startQuiz(t, ctx, s.quiz, req)
+			quizID := quiz.ID.String()
+			userID := joinQuiz(t, ctx, s.quiz, quizID, "alice")
+
+			// Several submissions race for each question, as from a client
+			// retrying over a flaky connection
+			const submissionsPerQuestion = 4
+			var (
+				mu       sync.Mutex
+				accepted = make(map[string][]*model.SubmitAnswerResponse)
+				wg       sync.WaitGroup
+				start    = make(chan struct{})
+			)
+			for _, question := range quiz.Questions {
+				for i := 0; i < submissionsPerQuestion; i++ {
+					wg.Add(1)
+					go func(questionID string) {
+						defer wg.Done()
+						<-start
+						resp, err := s.quiz.SubmitAnswer(ctx, userID, quizID, &model.SubmitAnswerRequest{QuestionID: questionID, Answer: "a"})
+						if err != nil {
+							return
+						}
+						mu.Lock()
+						defer mu.Unlock()
+						accepted[questionID] = append(accepted[questionID], resp)
+					}(question.ID.String())
+				}
+			}
+			close(start)
+			wg.Wait()
+
+			var responses []*model.SubmitAnswerResponse
+			for _, question := range quiz.Questions {
+				got := accepted[question.ID.String()]
+				if len(got) != 1 {
+					t.Fatalf("question worth %d accepted %d answers, want 1", question.Points, len(got))
+				}
+				responses = append(responses, got[0])
+			}
+
+			// In commit order each response's score adds exactly its own
+			// points to the one before, so scores never go down
+			sort.Slice(responses, func(i, j int) bool { return responses[i].NewScore < responses[j].NewScore })
+			total := 0
+			for _, resp := range responses {
+				total += resp.Points
+				if resp.NewScore != total {
+					t.Fatalf("responses %+v do not step up by their points", responses)
+				}
+			}
+
+			score, err := s.quizRepo.GetUserScore(ctx, uuid.MustParse(userID), quiz.ID)
+			if err != nil {
+				t.Fatalf("get score: %v", err)
+			}
+			if score != total {
+				t.Fatalf("stored score = %d, want %d", score, total)
+			}
+		})
+	}
+}
+
+func TestArchivedQuizIsFinished(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	quiz := startQuiz(t, ctx, s.quiz, &model.CreateQuizRequest{
+		Title: "Archived",
+		Questions: []model.QuestionRequest{
+			{QuestionText: "1+1", Options: []string{"1", "2"}, CorrectAnswer: "2", Points: 10},
+		},
+	})
+	quizID := quiz.ID.String()
+	userID := joinQuiz(t, ctx, s.quiz, quizID, "alice")
+	if _, err := s.quiz.UpdateQuizStatus(ctx, quizID, "completed"); err != nil {
+		t.Fatalf("complete quiz: %v", err)
+	}
+	if err := s.quizRepo.ArchiveQuizzes(ctx, []uuid.UUID{quiz.ID}); err != nil {
+		t.Fatalf("archive quiz: %v", err)
+	}
+
+	// Read through an instance whose cache has not seen the quiz yet
+	fresh := newTestServicesOver(t, s.quizRepo, repository.NewMemoryRedisRepository(config.Redis{}))
+	req := &model.SubmitAnswerRequest{QuestionID: quiz.Questions[0].ID.String(), Answer: "2"}
+	if _, err := fresh.quiz.SubmitAnswer(ctx, userID, quizID, req); err == nil {
+		t.Fatal("answer accepted after the quiz was archived")
+	}
+	if _, err := fresh.quiz.GetQuizReview(ctx, userID, quizID); err != nil {
+		t.Fatalf("review of archived quiz: %v", err)
+	}
+}
+
+func TestExpireQuizzesSkipsQuizLockedByAnotherInstance(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	quiz := &model.QuizSession{
+		ID:        uuid.New(),
+		Title:     "Expired",
+		Status:    "active",
+		Mode:      "live",
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	if err := s.quizRepo.CreateQuiz(ctx, quiz); err != nil {
+		t.Fatalf("create quiz: %v", err)
+	}
+
+	// Another instance is expiring the quiz
+	lockKey := "lock:quiz_expire:" + quiz.ID.String()
+	token, acquired, err := s.redisRepo.AcquireLock(ctx, lockKey, time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("acquire lock: %v, %v", acquired, err)
+	}
+	if n, err := s.quiz.ExpireQuizzes(ctx); err != nil || n != 0 {
+		t.Fatalf("expire while locked = %d, %v; want 0", n, err)
+	}
+	if err := s.redisRepo.ReleaseLock(ctx, lockKey, token); err != nil {
+		t.Fatalf("release lock: %v", err)
+	}
+
+	if n, err := s.quiz.ExpireQuizzes(ctx); err != nil || n != 1 {
+		t.Fatalf("expire = %d, %v; want 1", n, err)
+	}
+	got, err := s.quizRepo.GetQuiz(ctx, quiz.ID)
+	if err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	if got.Status != "completed" {
+		t.Fatalf("status = %q, want completed", got.Status)
+	}
+}
+
+func TestExpireQuizzesClearsLobbyAndPresence(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	quiz := &model.QuizSession{
+		ID:        uuid.New(),
+		Title:     "Expired lobby",
+		Status:    "waiting",
+		Mode:      "live",
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	if err := s.quizRepo.CreateQuiz(ctx, quiz); err != nil {
+		t.Fatalf("create quiz: %v", err)
+	}
+	quizID := quiz.ID.String()
+	userID := uuid.NewString()
+	if err := s.redisRepo.AddLobbyParticipant(ctx, quizID, userID, "alice"); err != nil {
+		t.Fatalf("add to lobby: %v", err)
+	}
+	if _, err := s.redisRepo.TouchPresence(ctx, quizID, userID, time.Minute); err != nil {
+		t.Fatalf("touch presence: %v", err)
+	}
+
+	if n, err := s.quiz.ExpireQuizzes(ctx); err != nil || n != 1 {
+		t.Fatalf("expire = %d, %v; want 1", n, err)
+	}
+	entries, err := s.quiz.InspectQuizCache(ctx, quizID)
+	if err != nil {
+		t.Fatalf("inspect cache: %v", err)
+	}
+	for _, key := range []string{"lobby:" + quizID, "presence:" + quizID} {
+		found := false
+		for _, entry := range entries {
+			if entry.Key != key {
+				continue
+			}
+			found = true
+			if entry.Exists {
+				t.Fatalf("%s survived expiry", key)
+			}
+		}
+		if !found {
+			t.Fatalf("inspect cache does not report %s", key)
+		}
+	}
+}
+
+func TestUpdateQuizStatusOnlyMovesForward(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	quiz, err := s.quiz.CreateQuiz(ctx, &model.CreateQuizRequest{
+		Title: "Transitions",
+		Questions: []model.QuestionRequest{
+			{QuestionText: "1+1", Options: []string{"1", "2"}, CorrectAnswer: "2", Points: 10},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create quiz: %v", err)
+	}
+	quizID := quiz.ID.String()
+
+	steps := []struct {
+		status string
+		ok     bool
+	}{
+		{"completed", false}, // a quiz cannot finish before it starts
+		{"active", true},
+		{"waiting", false},
+		{"completed", true},
+		{"active", false}, // answers are revealed, so it never reopens
+	}
+	for _, step := range steps {
+		_, err := s.quiz.UpdateQuizStatus(ctx, quizID, step.status)
+		if (err == nil) != step.ok {
+			t.Fatalf("set status %s: err = %v, want ok = %v", step.status, err, step.ok)
+		}
+	}
+}
+
+func TestCreateQuizScheduledDaysAheadExpiresAfterItOpens(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	startsAt := time.Now().Add(72 * time.Hour)
+	quiz, err := s.quiz.CreateQuiz(ctx, &model.CreateQuizRequest{
+		Title:    "Next week",
+		StartsAt: &startsAt,
+		Questions: []model.QuestionRequest{
+			{QuestionText: "1+1", Options: []string{"1", "2"}, CorrectAnswer: "2", Points: 10},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create quiz: %v", err)
+	}
+	if want := startsAt.Add(24 * time.Hour); !quiz.ExpiresAt.Equal(want) {
+		t.Fatalf("expires_at = %v, want %v", quiz.ExpiresAt, want)
+	}
+}
+
+func TestAfterAnswerRevealsOnlyWhatTheParticipantAnswered(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	quiz := startQuiz(t, ctx, s.quiz, &model.CreateQuizRequest{
+		Title:            "Reveal",
+		ReviewVisibility: "after_answer",
+		Questions: []model.QuestionRequest{
+			{QuestionText: "1+1", Options: []string{"1", "2"}, CorrectAnswer: "2", Points: 10},
+			{QuestionText: "2+2", Options: []string{"3", "4"}, CorrectAnswer: "4", Points: 10},
+		},
+	})
+	quizID := quiz.ID.String()
+	userID := joinQuiz(t, ctx, s.quiz, quizID, "alice")
+	answered := quiz.Questions[0]
+	if answered.Order != 0 {
+		answered = quiz.Questions[1]
+	}
+	req := &model.SubmitAnswerRequest{QuestionID: answered.ID.String(), Answer: answered.CorrectAnswer}
+	if _, err := s.quiz.SubmitAnswer(ctx, userID, quizID, req); err != nil {
+		t.Fatalf("submit answer: %v", err)
+	}
+
+	review, err := s.quiz.GetQuizReview(ctx, userID, quizID)
+	if err != nil {
+		t.Fatalf("review: %v", err)
+	}
+	for _, q := range review.Questions {
+		if want := q.QuestionID == answered.ID; q.Revealed != want || (q.CorrectAnswer != "") != want {
+			t.Fatalf("question %d revealed = %v with answer %q; want revealed = %v", q.Order, q.Revealed, q.CorrectAnswer, want)
+		}
+	}
+}
+
+// failingAttemptRepository fails every attempt lookup, as a database outage
+// would.
+type failingAttemptRepository struct {
+	repository.QuizRepository
+}
+
+func (r failingAttemptRepository) GetAttempt(ctx context.Context, quizID, userID uuid.UUID) (*model.QuizAttempt, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestReviewReportsAttemptLookupFailure(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	quiz := startQuiz(t, ctx, s.quiz, &model.CreateQuizRequest{
+		Title:            "Practice",
+		Mode:             "self_paced",
+		ReviewVisibility: "after_answer",
+		Questions: []model.QuestionRequest{
+			{QuestionText: "1+1", Options: []string{"1", "2"}, CorrectAnswer: "2", Points: 10},
+		},
+	})
+	userID := joinQuiz(t, ctx, s.quiz, quiz.ID.String(), "alice")
+
+	broken := newTestServicesOver(t, failingAttemptRepository{s.quizRepo}, s.redisRepo)
+	if _, err := broken.quiz.GetQuizReview(ctx, userID, quiz.ID.String()); err == nil {
+		t.Fatal("review succeeded although attempts could not be read")
+	}
+}
+
+func TestListAttemptsTimesOutOnlyTheCallersAttempt(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	quiz := startQuiz(t, ctx, s.quiz, &model.CreateQuizRequest{
+		Title: "Practice",
+		Mode:  "self_paced",
+		Questions: []model.QuestionRequest{
+			{QuestionText: "1+1", Options: []string{"1", "2"}, CorrectAnswer: "2", Points: 10},
+		},
+	})
+	quizID := quiz.ID.String()
+
+	// Both participants ran out of time a minute ago
+	attempts := make(map[string]uuid.UUID)
+	for _, name := range []string{"alice", "bob"} {
+		userID := joinQuiz(t, ctx, s.quiz, quizID, name)
+		attempts[name] = uuid.MustParse(userID)
+		err := s.quizRepo.CreateAttempt(ctx, &model.QuizAttempt{
+			ID:            uuid.New(),
+			QuizSessionID: quiz.ID,
+			UserID:        uuid.MustParse(userID),
+			Number:        1,
+			Status:        "in_progress",
+			StartedAt:     time.Now().Add(-2 * time.Minute),
+			Deadline:      time.Now().Add(-time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("create attempt: %v", err)
+		}
+	}
+
+	listed, err := s.quiz.ListAttempts(ctx, attempts["alice"].String(), quizID)
+	if err != nil || len(listed) != 1 || listed[0].Status != "timed_out" {
+		t.Fatalf("alice's attempts = %+v, %v; want one timed out", listed, err)
+	}
+	bob, err := s.quizRepo.GetAttempt(ctx, quiz.ID, attempts["bob"])
+	if err != nil || bob.Status != "in_progress" {
+		t.Fatalf("bob's attempt = %+v, %v; want left to the cleanup worker", bob, err)
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"quiz-app/internal/model"
+	"quiz-app/internal/repository"
+	"quiz-app/internal/tracing"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type SeriesService interface {
+	CreateSeries(ctx context.Context, req *model.CreateSeriesRequest) (*model.Series, error)
+	GetSeries(ctx context.Context, seriesID string) (*model.Series, error)
+	AddQuiz(ctx context.Context, seriesID string, req *model.AddSeriesQuizRequest) (*model.Series, error)
+	GetSeriesLeaderboard(ctx context.Context, seriesID string) ([]model.LeaderboardEntry, error)
+	RefreshSeriesLeaderboard(ctx context.Context, seriesID string)
+}
+
+type seriesService struct {
+	quizRepo  repository.QuizRepository
+	redisRepo repository.RedisRepository
+	wsService WebSocketService
+}
+
+func NewSeriesService(quizRepo repository.QuizRepository, redisRepo repository.RedisRepository, wsService WebSocketService) SeriesService {
+	return &seriesService{
+		quizRepo:  quizRepo,
+		redisRepo: redisRepo,
+		wsService: wsService,
+	}
+}
+
+func (s *seriesService) CreateSeries(ctx context.Context, req *model.CreateSeriesRequest) (*model.Series, error) {
+	series := &model.Series{
+		ID:          uuid.New(),
+		Title:       req.Title,
+		Aggregation: req.Aggregation,
+		BestN:       req.BestN,
+		CreatedAt:   time.Now(),
+	}
+	if series.Aggregation == "" {
+		series.Aggregation = "sum"
+	}
+	if series.Aggregation == "best_n" && series.BestN < 1 {
+		return nil, fmt.Errorf("best_n must be at least 1 for best_n aggregation")
+	}
+
+	if err := s.quizRepo.CreateSeries(ctx, series); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (s *seriesService) GetSeries(ctx context.Context, seriesID string) (*model.Series, error) {
+	seriesUUID, err := uuid.Parse(seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid series ID")
+	}
+
+	series, err := s.quizRepo.GetSeries(ctx, seriesUUID)
+	if err != nil {
+		return nil, fmt.Errorf("series not found")
+	}
+
+	return series, nil
+}
+
+func (s *seriesService) AddQuiz(ctx context.Context, seriesID string, req *model.AddSeriesQuizRequest) (*model.Series, error) {
+	series, err := s.GetSeries(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	quizUUID, err := uuid.Parse(req.QuizID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiz ID")
+	}
+
+	if err := s.quizRepo.AddQuizToSeries(ctx, series.ID, quizUUID); err != nil {
+		return nil, fmt.Errorf("quiz not found")
+	}
+
+	// The cached quiz still carries its old series
+	if err := s.redisRepo.DeleteKey(ctx, fmt.Sprintf("quiz:%s", quizUUID)); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate quiz cache", "quiz_id", quizUUID, "error", err)
+	}
+
+	s.RefreshSeriesLeaderboard(ctx, seriesID)
+
+	return s.GetSeries(ctx, seriesID)
+}
+
+func (s *seriesService) GetSeriesLeaderboard(ctx context.Context, seriesID string) ([]model.LeaderboardEntry, error) {
+	cachedLeaderboard, err := s.redisRepo.GetSeriesLeaderboard(ctx, seriesID)
+	if err == nil && len(cachedLeaderboard) > 0 {
+		return cachedLeaderboard, nil
+	}
+
+	series, err := s.GetSeries(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores, err := s.quizRepo.GetSeriesScores(ctx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyAttemptPolicies(ctx, series, scores); err != nil {
+		return nil, err
+	}
+
+	leaderboard := aggregateSeriesScores(series, scores)
+
+	// Update Redis cache
+	s.redisRepo.UpdateSeriesLeaderboard(ctx, seriesID, leaderboard)
+
+	return leaderboard, nil
+}
+
+// applyAttemptPolicies replaces the answer totals of self-paced quizzes,
+// which add up every attempt, with the score the quiz's own leaderboard
+// gives each user under its scoring policy.
+func (s *seriesService) applyAttemptPolicies(ctx context.Context, series *model.Series, scores []model.SeriesScore) error {
+	for _, quiz := range series.Quizzes {
+		if quiz.Mode != "self_paced" {
+			continue
+		}
+
+		attempts, err := s.quizRepo.GetAttemptScores(ctx, quiz.ID)
+		if err != nil {
+			return err
+		}
+		attemptsByUser := make(map[uuid.UUID][]model.AttemptScore)
+		for _, a := range attempts {
+			attemptsByUser[a.UserID] = append(attemptsByUser[a.UserID], a)
+		}
+
+		for i := range scores {
+			if scores[i].QuizID == quiz.ID {
+				scores[i].Score = applyScoringPolicy(quiz.ScoringPolicy, attemptsByUser[scores[i].UserID])
+			}
+		}
+	}
+	return nil
+}
+
+// RefreshSeriesLeaderboard drops the cached series leaderboard and pushes a
+// fresh one to live viewers, if any.
+func (s *seriesService) RefreshSeriesLeaderboard(ctx context.Context, seriesID string) {
+	key := fmt.Sprintf("series_leaderboard:%s", seriesID)
+	if err := s.redisRepo.DeleteKey(ctx, key); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate series leaderboard cache", "series_id", seriesID, "error", err)
+	}
+
+	if !s.wsService.HasSeriesViewers(seriesID) {
+		return
+	}
+
+	ctx, span := tracing.Start(ctx, "seriesService.broadcastSeriesLeaderboard", attribute.String("series.id", seriesID))
+	defer span.End()
+
+	leaderboard, err := s.GetSeriesLeaderboard(ctx, seriesID)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Failed to load series leaderboard for broadcast", "series_id", seriesID, "error", err)
+		return
+	}
+	slog.DebugContext(ctx, "Broadcasting series leaderboard update", "series_id", seriesID, "entries", len(leaderboard))
+	_, broadcast := tracing.Start(ctx, "websocket.broadcast", attribute.String("hub", "series:"+seriesID))
+	s.wsService.BroadcastSeriesLeaderboardUpdate(seriesID, leaderboard)
+	broadcast.End()
+}
+
+// aggregateSeriesScores combines each user's per-quiz scores according to the
+// series aggregation. Averages are taken over the quizzes a user played.
+func aggregateSeriesScores(series *model.Series, scores []model.SeriesScore) []model.LeaderboardEntry {
+	type userScores struct {
+		username string
+		scores   []int
+	}
+	byUser := make(map[uuid.UUID]*userScores)
+	for _, sc := range scores {
+		u, ok := byUser[sc.UserID]
+		if !ok {
+			u = &userScores{username: sc.Username}
+			byUser[sc.UserID] = u
+		}
+		u.scores = append(u.scores, sc.Score)
+	}
+
+	leaderboard := make([]model.LeaderboardEntry, 0, len(byUser))
+	for userID, u := range byUser {
+		counted := u.scores
+		if series.Aggregation == "best_n" {
+			sort.Sort(sort.Reverse(sort.IntSlice(counted)))
+			if len(counted) > series.BestN {
+				counted = counted[:series.BestN]
+			}
+		}
+
+		total := 0
+		for _, score := range counted {
+			total += score
+		}
+		if series.Aggregation == "average" {
+			total = int(math.Round(float64(total) / float64(len(counted))))
+		}
+
+		leaderboard = append(leaderboard, model.LeaderboardEntry{
+			UserID:   userID,
+			Username: u.username,
+			Score:    total,
+		})
+	}
+
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].Score != leaderboard[j].Score {
+			return leaderboard[i].Score > leaderboard[j].Score
+		}
+		return leaderboard[i].Username < leaderboard[j].Username
+	})
+	for i := range leaderboard {
+		leaderboard[i].Rank = i + 1
+	}
+
+	return leaderboard
+}
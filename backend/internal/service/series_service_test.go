@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"quiz-app/internal/config"
+	"quiz-app/internal/model"
+	"quiz-app/internal/repository"
+	"testing"
+)
+
+// testServices wires the services over the in-memory repositories.
+type testServices struct {
+	quiz      QuizService
+	series    SeriesService
+	quizRepo  repository.QuizRepository
+	redisRepo repository.RedisRepository
+}
+
+func newTestServices(t testing.TB) testServices {
+	t.Helper()
+	return newTestServicesOver(t, repository.NewMemoryQuizRepository(), repository.NewMemoryRedisRepository(config.Redis{}))
+}
+
+// newTestServicesOver wires the services over the given repositories, so
+// that several sets sharing them act like instances of one deployment.
+func newTestServicesOver(t testing.TB, quizRepo repository.QuizRepository, redisRepo repository.RedisRepository) testServices {
+	t.Helper()
+	wsService := NewWebSocketService(redisRepo)
+	seriesService := NewSeriesService(quizRepo, redisRepo, wsService)
+	presenceService := NewPresenceService(redisRepo, wsService, config.Presence{})
+	quizService := NewQuizService(quizRepo, redisRepo, wsService, seriesService, presenceService, config.Redis{})
+	t.Cleanup(func() {
+		if err := quizService.Drain(context.Background()); err != nil {
+			t.Errorf("drain: %v", err)
+		}
+	})
+	return testServices{quiz: quizService, series: seriesService, quizRepo: quizRepo, redisRepo: redisRepo}
+}
+
+// startQuiz creates a quiz from req and makes it active.
+func startQuiz(t testing.TB, ctx context.Context, s QuizService, req *model.CreateQuizRequest) *model.QuizSession {
+	t.Helper()
+	quiz, err := s.CreateQuiz(ctx, req)
+	if err != nil {
+		t.Fatalf("create quiz: %v", err)
+	}
+	if _, err := s.UpdateQuizStatus(ctx, quiz.ID.String(), "active"); err != nil {
+		t.Fatalf("start quiz: %v", err)
+	}
+	return quiz
+}
+
+func joinQuiz(t testing.TB, ctx context.Context, s QuizService, quizID, username string) string {
+	t.Helper()
+	user, _, err := s.JoinQuiz(ctx, quizID, &model.JoinQuizRequest{Username: username})
+	if err != nil {
+		t.Fatalf("join quiz as %s: %v", username, err)
+	}
+	return user.ID.String()
+}
+
+func TestSeriesLeaderboardAppliesAttemptScoringPolicy(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t)
+
+	series, err := s.series.CreateSeries(ctx, &model.CreateSeriesRequest{Title: "Weekly"})
+	if err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+	quiz := startQuiz(t, ctx, s.quiz, &model.CreateQuizRequest{
+		Title:         "Practice",
+		SeriesID:      series.ID.String(),
+		Mode:          "self_paced",
+		MaxAttempts:   3,
+		ScoringPolicy: "best",
+		Questions: []model.QuestionRequest{
+			{QuestionText: "1+1", Options: []string{"1", "2"}, CorrectAnswer: "2", Points: 10},
+			{QuestionText: "2+2", Options: []string{"3", "4"}, CorrectAnswer: "4", Points: 5},
+		},
+	})
+	quizID := quiz.ID.String()
+	userID := joinQuiz(t, ctx, s.quiz, quizID, "alice")
+
+	// Attempt 1 scores 15, attempt 2 scores 10; best is 15, not the 25 total
+	for _, answers := range [][]string{{"2", "4"}, {"2", "3"}} {
+		if _, err := s.quiz.StartAttempt(ctx, userID, quizID); err != nil {
+			t.Fatalf("start attempt: %v", err)
+		}
+		for i, answer := range answers {
+			req := &model.SubmitAnswerRequest{QuestionID: quiz.Questions[i].ID.String(), Answer: answer}
+			if _, err := s.quiz.SubmitAnswer(ctx, userID, quizID, req); err != nil {
+				t.Fatalf("submit answer: %v", err)
+			}
+		}
+		if _, err := s.quiz.SubmitAttempt(ctx, userID, quizID); err != nil {
+			t.Fatalf("submit attempt: %v", err)
+		}
+	}
+
+	leaderboard, err := s.series.GetSeriesLeaderboard(ctx, series.ID.String())
+	if err != nil {
+		t.Fatalf("series leaderboard: %v", err)
+	}
+	if len(leaderboard) != 1 || leaderboard[0].Score != 15 {
+		t.Fatalf("series leaderboard = %+v, want alice with 15", leaderboard)
+	}
+}
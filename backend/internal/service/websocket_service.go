@@ -1,12 +1,17 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
+	"quiz-app/internal/metrics"
 	"quiz-app/internal/model"
 	"quiz-app/internal/repository"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -15,26 +20,63 @@ type WebSocketService interface {
 	UnregisterLeaderboardViewer(quizID string, conn *websocket.Conn)
 	HasLeaderboardViewers(quizID string) bool
 	BroadcastLeaderboardUpdate(quizID string, leaderboard []model.LeaderboardEntry)
+	RegisterTeamLeaderboardViewer(quizID string, conn *websocket.Conn)
+	HasTeamLeaderboardViewers(quizID string) bool
+	BroadcastTeamLeaderboardUpdate(quizID string, leaderboard []model.TeamLeaderboardEntry)
+	RegisterSeriesViewer(seriesID string, conn *websocket.Conn)
+	HasSeriesViewers(seriesID string) bool
+	BroadcastSeriesLeaderboardUpdate(seriesID string, leaderboard []model.LeaderboardEntry)
+	RegisterParticipant(quizID string, conn *websocket.Conn, hooks ClientHooks)
+	RegisterLobbyClient(quizID string, conn *websocket.Conn, hooks ClientHooks)
+	HasLobbyViewers(quizID string) bool
+	BroadcastLobbyUpdate(quizID string, update model.LobbyUpdate)
+	BroadcastQuizEvent(quizID string, eventType string, data any)
+	CloseQuizHubs(quizID string, reason string)
+	Shutdown(ctx context.Context, reason string) error
+}
+
+// ClientHooks lets callers react to a client's incoming messages and to its
+// disconnection. Either hook may be nil.
+type ClientHooks struct {
+	OnMessage func(message []byte)
+	OnClose   func()
 }
 
 type Client struct {
-	conn   *websocket.Conn
-	send   chan []byte
-	quizID string
+	conn    *websocket.Conn
+	send    chan []byte
+	channel string
+	hooks   ClientHooks
+
+	// closeFrame is written when send is closed; empty means a bare close
+	closeFrame []byte
+	done       func()
 }
 
+// Hub fans messages out to every client of one channel. Quiz leaderboards
+// use the quiz ID as channel; other channels are prefixed (see teamChannel,
+// lobbyChannel and seriesChannel).
 type Hub struct {
 	clients    map[*Client]bool
 	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
-	quizID     string
+	done       chan struct{}
+	channel    string
+
+	// closeFrame is handed to every client when the hub is closed
+	closeFrame []byte
 }
 
 type webSocketService struct {
 	hubs      map[string]*Hub
 	hubsMutex sync.RWMutex
 	redisRepo repository.RedisRepository
+
+	// closing refuses new clients once Shutdown has started; writers tracks
+	// open connections so Shutdown can wait for their close frames
+	closing bool
+	writers sync.WaitGroup
 }
 
 func NewWebSocketService(redisRepo repository.RedisRepository) WebSocketService {
@@ -46,11 +88,26 @@ func NewWebSocketService(redisRepo repository.RedisRepository) WebSocketService
 	return service
 }
 
-func (s *webSocketService) getOrCreateHub(quizID string) *Hub {
+func teamChannel(quizID string) string {
+	return "team:" + quizID
+}
+
+func seriesChannel(seriesID string) string {
+	return "series:" + seriesID
+}
+
+// getOrCreateHub returns nil once the service is shutting down. Otherwise the
+// caller must call s.writers.Done when its client goes away.
+func (s *webSocketService) getOrCreateHub(channel string) *Hub {
 	s.hubsMutex.Lock()
 	defer s.hubsMutex.Unlock()
 
-	if hub, exists := s.hubs[quizID]; exists {
+	if s.closing {
+		return nil
+	}
+	s.writers.Add(1)
+
+	if hub, exists := s.hubs[channel]; exists {
 		return hub
 	}
 
@@ -59,29 +116,86 @@ func (s *webSocketService) getOrCreateHub(quizID string) *Hub {
 		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
-		quizID:     quizID,
+		done:       make(chan struct{}),
+		channel:    channel,
 	}
 
-	s.hubs[quizID] = hub
+	s.hubs[channel] = hub
 	go hub.run()
 
 	return hub
 }
 
-func (s *webSocketService) RegisterLeaderboardViewer(quizID string, conn *websocket.Conn) {
-	hub := s.getOrCreateHub(quizID)
+func lobbyChannel(quizID string) string {
+	return "lobby:" + quizID
+}
+
+func (s *webSocketService) registerViewer(channel string, conn *websocket.Conn) {
+	s.registerClient(channel, conn, ClientHooks{})
+}
+
+func (s *webSocketService) registerClient(channel string, conn *websocket.Conn, hooks ClientHooks) {
+	hub := s.getOrCreateHub(channel)
+	if hub == nil {
+		// Server is shutting down
+		conn.WriteMessage(websocket.CloseMessage, restartFrame)
+		conn.Close()
+		return
+	}
+
 	client := &Client{
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		quizID: quizID,
+		conn:    conn,
+		send:    make(chan []byte, 256),
+		channel: channel,
+		hooks:   hooks,
+		done:    s.writers.Done,
 	}
 
-	hub.register <- client
+	select {
+	case hub.register <- client:
+	case <-hub.done:
+		// Hub was closed while we were connecting
+		s.writers.Done()
+		conn.Close()
+		if hooks.OnClose != nil {
+			hooks.OnClose()
+		}
+		return
+	}
 
 	go client.writePump()
 	go client.readPump(hub)
 }
 
+func (s *webSocketService) hasViewers(channel string) bool {
+	s.hubsMutex.RLock()
+	defer s.hubsMutex.RUnlock()
+
+	hub, exists := s.hubs[channel]
+	if !exists {
+		return false
+	}
+
+	return len(hub.clients) > 0
+}
+
+func (s *webSocketService) broadcast(channel string, message []byte) {
+	s.hubsMutex.RLock()
+	hub, exists := s.hubs[channel]
+	s.hubsMutex.RUnlock()
+
+	if exists {
+		select {
+		case hub.broadcast <- message:
+		case <-hub.done:
+		}
+	}
+}
+
+func (s *webSocketService) RegisterLeaderboardViewer(quizID string, conn *websocket.Conn) {
+	s.registerViewer(quizID, conn)
+}
+
 func (s *webSocketService) UnregisterLeaderboardViewer(quizID string, conn *websocket.Conn) {
 	s.hubsMutex.RLock()
 	hub, exists := s.hubs[quizID]
@@ -94,22 +208,17 @@ func (s *webSocketService) UnregisterLeaderboardViewer(quizID string, conn *webs
 	// Find and unregister client
 	for client := range hub.clients {
 		if client.conn == conn {
-			hub.unregister <- client
+			select {
+			case hub.unregister <- client:
+			case <-hub.done:
+			}
 			break
 		}
 	}
 }
 
 func (s *webSocketService) HasLeaderboardViewers(quizID string) bool {
-	s.hubsMutex.RLock()
-	defer s.hubsMutex.RUnlock()
-
-	hub, exists := s.hubs[quizID]
-	if !exists {
-		return false
-	}
-
-	return len(hub.clients) > 0
+	return s.hasViewers(quizID)
 }
 
 func (s *webSocketService) BroadcastLeaderboardUpdate(quizID string, leaderboard []model.LeaderboardEntry) {
@@ -120,68 +229,247 @@ func (s *webSocketService) BroadcastLeaderboardUpdate(quizID string, leaderboard
 
 	message, err := json.Marshal(update)
 	if err != nil {
-		log.Printf("Error marshaling leaderboard update: %v", err)
+		slog.Error("Failed to marshal leaderboard update", "quiz_id", quizID, "error", err)
 		return
 	}
 
-	s.hubsMutex.RLock()
-	hub, exists := s.hubs[quizID]
-	s.hubsMutex.RUnlock()
+	s.broadcast(quizID, message)
+}
 
-	if exists {
-		hub.broadcast <- message
+func (s *webSocketService) RegisterTeamLeaderboardViewer(quizID string, conn *websocket.Conn) {
+	s.registerViewer(teamChannel(quizID), conn)
+}
+
+func (s *webSocketService) HasTeamLeaderboardViewers(quizID string) bool {
+	return s.hasViewers(teamChannel(quizID))
+}
+
+func (s *webSocketService) BroadcastTeamLeaderboardUpdate(quizID string, leaderboard []model.TeamLeaderboardEntry) {
+	update := model.TeamLeaderboardUpdate{
+		Type:        "team_leaderboard_update",
+		Leaderboard: leaderboard,
+		UpdatedAt:   time.Now(),
+	}
+
+	message, err := json.Marshal(update)
+	if err != nil {
+		slog.Error("Failed to marshal team leaderboard update", "quiz_id", quizID, "error", err)
+		return
+	}
+
+	s.broadcast(teamChannel(quizID), message)
+}
+
+func (s *webSocketService) RegisterSeriesViewer(seriesID string, conn *websocket.Conn) {
+	s.registerViewer(seriesChannel(seriesID), conn)
+}
+
+func (s *webSocketService) HasSeriesViewers(seriesID string) bool {
+	return s.hasViewers(seriesChannel(seriesID))
+}
+
+func (s *webSocketService) BroadcastSeriesLeaderboardUpdate(seriesID string, leaderboard []model.LeaderboardEntry) {
+	seriesUUID, _ := uuid.Parse(seriesID)
+	update := model.SeriesLeaderboardUpdate{
+		Type:        "series_leaderboard_update",
+		SeriesID:    seriesUUID,
+		Leaderboard: leaderboard,
+		UpdatedAt:   time.Now(),
+	}
+
+	message, err := json.Marshal(update)
+	if err != nil {
+		slog.Error("Failed to marshal series leaderboard update", "series_id", seriesID, "error", err)
+		return
+	}
+
+	s.broadcast(seriesChannel(seriesID), message)
+}
+
+// RegisterParticipant subscribes a participant's own socket to the quiz
+// channel, so it receives the same updates as leaderboard viewers.
+func (s *webSocketService) RegisterParticipant(quizID string, conn *websocket.Conn, hooks ClientHooks) {
+	s.registerClient(quizID, conn, hooks)
+}
+
+func (s *webSocketService) RegisterLobbyClient(quizID string, conn *websocket.Conn, hooks ClientHooks) {
+	s.registerClient(lobbyChannel(quizID), conn, hooks)
+}
+
+func (s *webSocketService) HasLobbyViewers(quizID string) bool {
+	return s.hasViewers(lobbyChannel(quizID))
+}
+
+func (s *webSocketService) BroadcastLobbyUpdate(quizID string, update model.LobbyUpdate) {
+	message, err := json.Marshal(update)
+	if err != nil {
+		slog.Error("Failed to marshal lobby update", "quiz_id", quizID, "error", err)
+		return
+	}
+
+	s.broadcast(lobbyChannel(quizID), message)
+}
+
+// BroadcastQuizEvent sends a typed event (e.g. a countdown tick) to everyone
+// watching a quiz, including its waiting room.
+func (s *webSocketService) BroadcastQuizEvent(quizID string, eventType string, data any) {
+	message, err := json.Marshal(model.WSMessage{Type: eventType, Data: data})
+	if err != nil {
+		slog.Error("Failed to marshal quiz event", "quiz_id", quizID, "event", eventType, "error", err)
+		return
+	}
+
+	s.broadcast(quizID, message)
+	s.broadcast(lobbyChannel(quizID), message)
+}
+
+// CloseQuizHubs tells every viewer of a quiz why it is closing, then
+// disconnects them and drops the quiz's hubs.
+func (s *webSocketService) CloseQuizHubs(quizID string, reason string) {
+	message, err := json.Marshal(model.WSMessage{
+		Type: "quiz_closed",
+		Data: map[string]string{"quiz_id": quizID, "reason": reason},
+	})
+	if err != nil {
+		slog.Error("Failed to marshal quiz closed message", "quiz_id", quizID, "error", err)
+		return
+	}
+
+	for _, channel := range []string{quizID, teamChannel(quizID), lobbyChannel(quizID)} {
+		s.hubsMutex.Lock()
+		hub, exists := s.hubs[channel]
+		delete(s.hubs, channel)
+		s.hubsMutex.Unlock()
+
+		if !exists {
+			continue
+		}
+
+		select {
+		case hub.broadcast <- message:
+		case <-hub.done:
+		}
+		close(hub.done)
+		slog.Info("Closed hub", "hub", channel, "reason", reason)
+	}
+}
+
+// restartFrame tells clients the server is going away and they should
+// reconnect shortly.
+var restartFrame = websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting")
+
+// Shutdown refuses new connections, sends every client a close frame with
+// the given reason and waits until the frames have been written.
+func (s *webSocketService) Shutdown(ctx context.Context, reason string) error {
+	frame := websocket.FormatCloseMessage(websocket.CloseServiceRestart, reason)
+
+	s.hubsMutex.Lock()
+	s.closing = true
+	hubs := s.hubs
+	s.hubs = make(map[string]*Hub)
+	s.hubsMutex.Unlock()
+
+	for _, hub := range hubs {
+		hub.closeFrame = frame
+		close(hub.done)
+	}
+	slog.Info("Closing WebSocket hubs", "hubs", len(hubs), "reason", reason)
+
+	done := make(chan struct{})
+	go func() {
+		s.writers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("websocket clients still connected: %w", ctx.Err())
 	}
 }
 
 func (h *Hub) run() {
+	hubType := metrics.HubType(h.channel)
+	viewers := metrics.WebSocketViewers.WithLabelValues(h.channel)
+	fanout := metrics.BroadcastFanoutDuration.WithLabelValues(hubType)
+	dropped := metrics.DroppedClients.WithLabelValues(hubType)
+
 	for {
 		select {
 		case client := <-h.register:
 			h.clients[client] = true
-			log.Printf("Client registered for quiz %s. Total: %d", h.quizID, len(h.clients))
+			viewers.Set(float64(len(h.clients)))
+			slog.Debug("Client registered", "hub", h.channel, "clients", len(h.clients))
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
-				log.Printf("Client unregistered for quiz %s. Total: %d", h.quizID, len(h.clients))
+				viewers.Set(float64(len(h.clients)))
+				slog.Debug("Client unregistered", "hub", h.channel, "clients", len(h.clients))
 			}
 
 		case message := <-h.broadcast:
+			start := time.Now()
 			for client := range h.clients {
 				select {
 				case client.send <- message:
 				default:
+					// Too slow to keep up; drop it rather than stall the hub
 					close(client.send)
 					delete(h.clients, client)
+					dropped.Inc()
 				}
 			}
+			fanout.Observe(time.Since(start).Seconds())
+			viewers.Set(float64(len(h.clients)))
+
+		case <-h.done:
+			// Closing send makes each writePump send a close frame
+			for client := range h.clients {
+				client.closeFrame = h.closeFrame
+				close(client.send)
+				delete(h.clients, client)
+			}
+			metrics.WebSocketViewers.DeleteLabelValues(h.channel)
+			return
 		}
 	}
 }
 
 func (c *Client) readPump(hub *Hub) {
 	defer func() {
-		hub.unregister <- c
+		select {
+		case hub.unregister <- c:
+		case <-hub.done:
+		}
 		c.conn.Close()
+		if c.hooks.OnClose != nil {
+			c.hooks.OnClose()
+		}
 	}()
 
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			break
 		}
+		if c.hooks.OnMessage != nil {
+			c.hooks.OnMessage(message)
+		}
 	}
 }
 
 func (c *Client) writePump() {
+	defer c.done()
 	defer c.conn.Close()
 
 	for {
 		select {
 		case message, ok := <-c.send:
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.closeFrame)
 				return
 			}
 
@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// GormPlugin records a client span for every SQL statement, as a child of
+// the span in the context the query was issued with.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+type gormParentKey struct{}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	type register func(name string, fn func(*gorm.DB)) error
+	callbacks := db.Callback()
+	for operation, hooks := range map[string][2]register{
+		"create": {callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		"query":  {callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		"update": {callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		"delete": {callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		"row":    {callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		"raw":    {callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	} {
+		operation := operation
+		if err := hooks[0]("tracing:before_"+operation, func(tx *gorm.DB) {
+			parent := tx.Statement.Context
+			ctx, _ := Tracer().Start(parent, "db."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(dbSystem(tx), semconv.DBOperationName(operation)))
+			// Keep the parent so later statements on the same session are
+			// not nested under this one
+			tx.Statement.Context = context.WithValue(ctx, gormParentKey{}, parent)
+		}); err != nil {
+			return err
+		}
+		if err := hooks[1]("tracing:after_"+operation, func(tx *gorm.DB) {
+			ctx := tx.Statement.Context
+			parent, ok := ctx.Value(gormParentKey{}).(context.Context)
+			if !ok {
+				return
+			}
+			span := trace.SpanFromContext(ctx)
+			span.SetAttributes(
+				semconv.DBQueryText(tx.Statement.SQL.String()),
+				attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+			)
+			if tx.Statement.Table != "" {
+				span.SetAttributes(semconv.DBCollectionName(tx.Statement.Table))
+			}
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				span.RecordError(tx.Error)
+				span.SetStatus(codes.Error, tx.Error.Error())
+			}
+			span.End()
+			tx.Statement.Context = parent
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dbSystem(tx *gorm.DB) attribute.KeyValue {
+	switch tx.Dialector.Name() {
+	case "postgres":
+		return semconv.DBSystemPostgreSQL
+	case "sqlite":
+		return semconv.DBSystemSqlite
+	default:
+		return semconv.DBSystemKey.String(tx.Dialector.Name())
+	}
+}
+
+// RedisHook records a client span for every Redis command or pipeline.
+// redis.Nil only means a key was missing and is not an error.
+type RedisHook struct{}
+
+func (RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = Tracer().Start(ctx, "redis."+cmd.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(cmd.Name())))
+	return ctx, nil
+}
+
+func (RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endRedisSpan(trace.SpanFromContext(ctx), cmd.Err())
+	return nil
+}
+
+func (RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name()
+	}
+	ctx, _ = Tracer().Start(ctx, "redis.pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemRedis,
+			semconv.DBOperationName(strings.Join(names, " ")),
+			attribute.Int("db.redis.pipeline_length", len(cmds)),
+		))
+	return ctx, nil
+}
+
+func (RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && !errors.Is(cmdErr, redis.Nil) {
+			err = cmdErr
+			break
+		}
+	}
+	endRedisSpan(trace.SpanFromContext(ctx), err)
+	return nil
+}
+
+func endRedisSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
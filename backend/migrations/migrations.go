@@ -0,0 +1,8 @@
+// Package migrations embeds the versioned SQL schema, one directory per
+// database driver. Files are named NNN_name.up.sql and NNN_name.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
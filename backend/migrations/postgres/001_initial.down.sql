@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_answers;
+DROP TABLE IF EXISTS questions;
+DROP TABLE IF EXISTS quiz_sessions;
+DROP TABLE IF EXISTS users;
+DROP EXTENSION IF EXISTS "uuid-ossp";
@@ -0,0 +1 @@
+ALTER TABLE user_answers DROP COLUMN response_time_ms;
@@ -0,0 +1,2 @@
+-- Client-reported time taken to answer, used for participant stats
+ALTER TABLE user_answers ADD COLUMN response_time_ms INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- When correct answers are revealed: never, after_question, after_quiz
+ALTER TABLE quiz_sessions ADD COLUMN review_visibility VARCHAR(20) NOT NULL DEFAULT 'after_quiz';
@@ -0,0 +1,15 @@
+-- Final standings frozen when a quiz completes
+CREATE TABLE quiz_results (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    quiz_session_id UUID NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username VARCHAR(50) NOT NULL,
+    rank INTEGER NOT NULL,
+    score INTEGER NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_quiz_results_quiz_user ON quiz_results(quiz_session_id, user_id);
+CREATE INDEX idx_quiz_results_user_id ON quiz_results(user_id);
+CREATE INDEX idx_quiz_results_rank ON quiz_results(rank);
@@ -0,0 +1,2 @@
+ALTER TABLE quiz_sessions DROP COLUMN series_id;
+DROP TABLE IF EXISTS series;
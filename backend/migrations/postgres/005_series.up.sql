@@ -0,0 +1,12 @@
+-- Series group quiz sessions under a cumulative leaderboard
+CREATE TABLE series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(100) NOT NULL,
+    aggregation VARCHAR(20) NOT NULL DEFAULT 'sum',
+    best_n INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE quiz_sessions ADD COLUMN series_id UUID REFERENCES series(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_quiz_sessions_series_id ON quiz_sessions(series_id);
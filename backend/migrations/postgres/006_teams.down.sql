@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
+
+ALTER TABLE quiz_sessions DROP COLUMN team_scoring;
+ALTER TABLE quiz_sessions DROP COLUMN team_mode;
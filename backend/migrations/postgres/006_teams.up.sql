@@ -0,0 +1,22 @@
+-- Team play: teams are predefined by the host or self-selected at join
+ALTER TABLE quiz_sessions ADD COLUMN team_mode VARCHAR(20) NOT NULL DEFAULT 'none';
+ALTER TABLE quiz_sessions ADD COLUMN team_scoring VARCHAR(20) NOT NULL DEFAULT 'sum';
+
+CREATE TABLE teams (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    quiz_session_id UUID NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_teams_quiz_name ON teams(quiz_session_id, name);
+
+CREATE TABLE team_members (
+    quiz_session_id UUID NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (quiz_session_id, user_id)
+);
+
+CREATE INDEX idx_team_members_team_id ON team_members(team_id);
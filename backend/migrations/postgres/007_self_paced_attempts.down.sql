@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS quiz_attempts;
+
+ALTER TABLE quiz_sessions DROP COLUMN time_limit_seconds;
+ALTER TABLE quiz_sessions DROP COLUMN mode;
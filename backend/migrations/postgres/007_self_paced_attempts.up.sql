@@ -0,0 +1,17 @@
+-- Self-paced mode: participants start whenever they like within the quiz window
+ALTER TABLE quiz_sessions ADD COLUMN mode VARCHAR(20) NOT NULL DEFAULT 'live';
+ALTER TABLE quiz_sessions ADD COLUMN time_limit_seconds INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE quiz_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    quiz_session_id UUID NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress',
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deadline TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_quiz_attempts_quiz_user ON quiz_attempts(quiz_session_id, user_id);
+CREATE INDEX idx_quiz_attempts_status ON quiz_attempts(status);
+CREATE INDEX idx_quiz_attempts_deadline ON quiz_attempts(deadline);
@@ -0,0 +1,16 @@
+-- Only one answer per user and question, and one attempt per user, fit the
+-- old constraints; later attempts are discarded
+DELETE FROM user_answers WHERE attempt_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_user_answers_attempt_id;
+DROP INDEX IF EXISTS idx_user_answers_attempt_question;
+DROP INDEX IF EXISTS idx_user_answers_user_question_live;
+ALTER TABLE user_answers DROP COLUMN attempt_id;
+ALTER TABLE user_answers ADD CONSTRAINT user_answers_user_id_question_id_key UNIQUE (user_id, question_id);
+
+DELETE FROM quiz_attempts WHERE number > 1;
+DROP INDEX IF EXISTS idx_quiz_attempts_quiz_user_number;
+ALTER TABLE quiz_attempts DROP COLUMN number;
+CREATE UNIQUE INDEX idx_quiz_attempts_quiz_user ON quiz_attempts(quiz_session_id, user_id);
+
+ALTER TABLE quiz_sessions DROP COLUMN scoring_policy;
+ALTER TABLE quiz_sessions DROP COLUMN max_attempts;
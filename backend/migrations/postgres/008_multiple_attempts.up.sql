@@ -0,0 +1,15 @@
+-- Multiple attempts per participant with a scoring policy: best, latest, average
+ALTER TABLE quiz_sessions ADD COLUMN max_attempts INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE quiz_sessions ADD COLUMN scoring_policy VARCHAR(20) NOT NULL DEFAULT 'best';
+
+ALTER TABLE quiz_attempts ADD COLUMN number INTEGER NOT NULL DEFAULT 1;
+DROP INDEX IF EXISTS idx_quiz_attempts_quiz_user;
+CREATE UNIQUE INDEX idx_quiz_attempts_quiz_user_number ON quiz_attempts(quiz_session_id, user_id, number);
+
+-- Answers belong to an attempt in self-paced quizzes, so the same question
+-- can be answered once per attempt instead of once per user
+ALTER TABLE user_answers ADD COLUMN attempt_id UUID REFERENCES quiz_attempts(id) ON DELETE CASCADE;
+ALTER TABLE user_answers DROP CONSTRAINT IF EXISTS user_answers_user_id_question_id_key;
+CREATE UNIQUE INDEX idx_user_answers_user_question_live ON user_answers(user_id, question_id) WHERE attempt_id IS NULL;
+CREATE UNIQUE INDEX idx_user_answers_attempt_question ON user_answers(attempt_id, question_id) WHERE attempt_id IS NOT NULL;
+CREATE INDEX idx_user_answers_attempt_id ON user_answers(attempt_id);
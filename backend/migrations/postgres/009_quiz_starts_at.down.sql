@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_quiz_sessions_starts_at;
+ALTER TABLE quiz_sessions DROP COLUMN starts_at;
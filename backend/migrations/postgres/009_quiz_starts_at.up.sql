@@ -0,0 +1,4 @@
+-- Scheduled quizzes open automatically at starts_at
+ALTER TABLE quiz_sessions ADD COLUMN starts_at TIMESTAMP;
+
+CREATE INDEX idx_quiz_sessions_starts_at ON quiz_sessions(starts_at);
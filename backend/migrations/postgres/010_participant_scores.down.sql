@@ -0,0 +1 @@
+DROP TABLE IF EXISTS participant_scores;
@@ -0,0 +1,20 @@
+-- Running score per participant, maintained alongside every answer
+CREATE TABLE participant_scores (
+    quiz_session_id UUID NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    score INTEGER NOT NULL DEFAULT 0,
+    answers INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (quiz_session_id, user_id)
+);
+
+INSERT INTO participant_scores (quiz_session_id, user_id, score, answers, updated_at)
+SELECT
+    q.quiz_session_id,
+    ua.user_id,
+    SUM(CASE WHEN ua.is_correct THEN q.points ELSE 0 END),
+    COUNT(*),
+    MAX(ua.answered_at)
+FROM user_answers ua
+JOIN questions q ON ua.question_id = q.id
+GROUP BY q.quiz_session_id, ua.user_id;
@@ -0,0 +1,6 @@
+-- USING cannot contain a subquery, so convert through a temporary column
+ALTER TABLE questions ADD COLUMN options_array TEXT[];
+UPDATE questions SET options_array = ARRAY(SELECT json_array_elements_text(options::json));
+ALTER TABLE questions DROP COLUMN options;
+ALTER TABLE questions RENAME COLUMN options_array TO options;
+ALTER TABLE questions ALTER COLUMN options SET NOT NULL;
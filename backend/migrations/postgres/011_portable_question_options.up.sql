@@ -0,0 +1,3 @@
+-- Store question options as a JSON array in a text column so the same schema
+-- works on SQLite.
+ALTER TABLE questions ALTER COLUMN options TYPE TEXT USING array_to_json(options)::text;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_quiz_sessions_completed_at;
+ALTER TABLE quiz_sessions DROP COLUMN completed_at;
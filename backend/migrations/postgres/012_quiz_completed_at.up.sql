@@ -0,0 +1,11 @@
+-- When a quiz finished, so retention counts from completion rather than expiry
+ALTER TABLE quiz_sessions ADD COLUMN completed_at TIMESTAMP;
+
+UPDATE quiz_sessions
+SET completed_at = COALESCE(
+    (SELECT MAX(r.created_at) FROM quiz_results r WHERE r.quiz_session_id = quiz_sessions.id),
+    expires_at
+)
+WHERE status IN ('completed', 'archived');
+
+CREATE INDEX idx_quiz_sessions_completed_at ON quiz_sessions(completed_at);
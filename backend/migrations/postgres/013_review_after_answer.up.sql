@@ -0,0 +1,3 @@
+-- after_question revealed each answer once the participant had answered it,
+-- not when the question closed for everyone; the name now says so
+UPDATE quiz_sessions SET review_visibility = 'after_answer' WHERE review_visibility = 'after_question';
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS participant_scores;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS quiz_results;
+DROP TABLE IF EXISTS user_answers;
+DROP TABLE IF EXISTS quiz_attempts;
+DROP TABLE IF EXISTS questions;
+DROP TABLE IF EXISTS quiz_sessions;
+DROP TABLE IF EXISTS series;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,123 @@
+-- SQLite schema equivalent to the Postgres migrations up to
+-- 011_portable_question_options. IDs are generated by the application.
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE series (
+    id TEXT PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    aggregation VARCHAR(20) NOT NULL DEFAULT 'sum',
+    best_n INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE quiz_sessions (
+    id TEXT PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    status VARCHAR(20) DEFAULT 'waiting',
+    review_visibility VARCHAR(20) NOT NULL DEFAULT 'after_quiz',
+    series_id TEXT REFERENCES series(id) ON DELETE SET NULL,
+    team_mode VARCHAR(20) NOT NULL DEFAULT 'none',
+    team_scoring VARCHAR(20) NOT NULL DEFAULT 'sum',
+    mode VARCHAR(20) NOT NULL DEFAULT 'live',
+    time_limit_seconds INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 1,
+    scoring_policy VARCHAR(20) NOT NULL DEFAULT 'best',
+    starts_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME
+);
+
+CREATE INDEX idx_quiz_sessions_status ON quiz_sessions(status);
+CREATE INDEX idx_quiz_sessions_series_id ON quiz_sessions(series_id);
+CREATE INDEX idx_quiz_sessions_starts_at ON quiz_sessions(starts_at);
+
+CREATE TABLE questions (
+    id TEXT PRIMARY KEY,
+    quiz_session_id TEXT REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    question_text TEXT NOT NULL,
+    options TEXT NOT NULL,
+    correct_answer VARCHAR(255) NOT NULL,
+    points INTEGER DEFAULT 10,
+    "order" INTEGER NOT NULL
+);
+
+CREATE INDEX idx_questions_quiz_session_id ON questions(quiz_session_id);
+
+CREATE TABLE quiz_attempts (
+    id TEXT PRIMARY KEY,
+    quiz_session_id TEXT NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress',
+    started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deadline DATETIME NOT NULL,
+    ended_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_quiz_attempts_quiz_user_number ON quiz_attempts(quiz_session_id, user_id, number);
+CREATE INDEX idx_quiz_attempts_status ON quiz_attempts(status);
+CREATE INDEX idx_quiz_attempts_deadline ON quiz_attempts(deadline);
+
+CREATE TABLE user_answers (
+    id TEXT PRIMARY KEY,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    question_id TEXT REFERENCES questions(id) ON DELETE CASCADE,
+    attempt_id TEXT REFERENCES quiz_attempts(id) ON DELETE CASCADE,
+    answer VARCHAR(255) NOT NULL,
+    is_correct BOOLEAN NOT NULL,
+    response_time_ms INTEGER NOT NULL DEFAULT 0,
+    answered_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_answers_user_id ON user_answers(user_id);
+CREATE INDEX idx_user_answers_question_id ON user_answers(question_id);
+CREATE INDEX idx_user_answers_attempt_id ON user_answers(attempt_id);
+CREATE UNIQUE INDEX idx_user_answers_user_question_live ON user_answers(user_id, question_id) WHERE attempt_id IS NULL;
+CREATE UNIQUE INDEX idx_user_answers_attempt_question ON user_answers(attempt_id, question_id) WHERE attempt_id IS NOT NULL;
+
+CREATE TABLE quiz_results (
+    id TEXT PRIMARY KEY,
+    quiz_session_id TEXT NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username VARCHAR(50) NOT NULL,
+    rank INTEGER NOT NULL,
+    score INTEGER NOT NULL DEFAULT 0,
+    completed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_quiz_results_quiz_user ON quiz_results(quiz_session_id, user_id);
+CREATE INDEX idx_quiz_results_user_id ON quiz_results(user_id);
+CREATE INDEX idx_quiz_results_rank ON quiz_results(rank);
+
+CREATE TABLE teams (
+    id TEXT PRIMARY KEY,
+    quiz_session_id TEXT NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_teams_quiz_name ON teams(quiz_session_id, name);
+
+CREATE TABLE team_members (
+    quiz_session_id TEXT NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    team_id TEXT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (quiz_session_id, user_id)
+);
+
+CREATE INDEX idx_team_members_team_id ON team_members(team_id);
+
+CREATE TABLE participant_scores (
+    quiz_session_id TEXT NOT NULL REFERENCES quiz_sessions(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    score INTEGER NOT NULL DEFAULT 0,
+    answers INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (quiz_session_id, user_id)
+);
@@ -0,0 +1 @@
+UPDATE quiz_sessions SET review_visibility = 'after_question' WHERE review_visibility = 'after_answer';